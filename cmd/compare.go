@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/compare"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/report"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+)
+
+var tolerances = compare.DefaultTolerances()
+
+var compareCmd = &cobra.Command{
+	Use:   "compare <baseline-results> <current-results>",
+	Short: "Compare two runs and flag regressions",
+	Long: `Compare reads two results files written with --report json=path and reports
+the change in latency percentiles, error rates and monitor time to consistency
+of every simulation found in both.
+
+A change counts as a regression when it is worse than the configured
+tolerances. Latency and time to consistency tolerances are relative, so 0.1
+allows a 10% increase, and the error rate tolerance is absolute, so 0.01
+allows one more failed request in a hundred.
+
+Examples:
+  easy-test run --report json=baseline.json
+  easy-test compare baseline.json current.json
+  easy-test compare --latency-tolerance 0.25 --noise-floor 5ms baseline.json current.json
+
+Exit codes:
+  0  no regressions
+  1  the results could not be compared
+  2  one or more metrics regressed
+  3  invalid results files or flags`,
+	Args: cobra.ExactArgs(2),
+	RunE: compareRuns,
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().Float64Var(&tolerances.Latency, "latency-tolerance", tolerances.Latency,
+		"largest accepted relative increase of a latency percentile")
+	compareCmd.Flags().Float64Var(&tolerances.ErrorRate, "error-rate-tolerance", tolerances.ErrorRate,
+		"largest accepted absolute increase of an error rate")
+	compareCmd.Flags().Float64Var(&tolerances.TimeToConsistency, "consistency-tolerance", tolerances.TimeToConsistency,
+		"largest accepted relative increase of monitor time to consistency")
+	compareCmd.Flags().DurationVar(&tolerances.NoiseFloor, "noise-floor", tolerances.NoiseFloor,
+		"latency increases below this are never regressions")
+}
+
+func compareRuns(cmd *cobra.Command, args []string) error {
+	if tolerances.Latency < 0 || tolerances.ErrorRate < 0 || tolerances.TimeToConsistency < 0 || tolerances.NoiseFloor < 0 {
+		return NewConfigError(fmt.Errorf("tolerances can not be negative"))
+	}
+
+	baseline, err := ReadResultsFile(args[0])
+	if err != nil {
+		return NewConfigError(err)
+	}
+
+	current, err := ReadResultsFile(args[1])
+	if err != nil {
+		return NewConfigError(err)
+	}
+
+	return CompareResults(baseline, current, tolerances, cmd.OutOrStdout())
+}
+
+// ReadResultsFile reads a results file written with --report json=path.
+func ReadResultsFile(path string) ([]*simulation.SimulationResult, error) {
+	if err := ValidateFilePath(path); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results file: %w", err)
+	}
+	defer file.Close()
+
+	results, err := report.ReadJSON(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results file %s: %w", path, err)
+	}
+
+	return results, nil
+}
+
+// CompareResults writes the comparison of current against baseline to out and
+// returns a threshold error when anything regressed.
+func CompareResults(baseline []*simulation.SimulationResult, current []*simulation.SimulationResult, t compare.Tolerances, out io.Writer) error {
+	comparison := compare.Compare(baseline, current, t)
+	if err := comparison.WriteText(out); err != nil {
+		return err
+	}
+
+	if len(comparison.Deltas) == 0 {
+		return fmt.Errorf("no simulations in common to compare")
+	}
+
+	if comparison.Regressed() {
+		regressions := 0
+		for _, delta := range comparison.Deltas {
+			if delta.Regression {
+				regressions++
+			}
+		}
+
+		return NewThresholdError(fmt.Errorf("%d metric(s) regressed", regressions))
+	}
+
+	return nil
+}
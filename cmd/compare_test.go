@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/compare"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/report"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+)
+
+func writeResultsFile(t *testing.T, dir string, name string, p99 time.Duration) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create results file: %v", err)
+	}
+	defer file.Close()
+
+	result := &simulation.SimulationResult{
+		Name:    "Orders",
+		Summary: simulation.Summary{Requests: 10},
+		Latency: []*simulation.LatencyResult{{Kind: simulation.LatencyKindTarget, Name: "orders", P99: duration.Duration(p99)}},
+	}
+	if err := report.WriteJSON(file, []*simulation.SimulationResult{result}); err != nil {
+		t.Fatalf("failed to write results file: %v", err)
+	}
+
+	return path
+}
+
+func TestCompareResults(t *testing.T) {
+	dir := t.TempDir()
+	baselinePath := writeResultsFile(t, dir, "baseline.json", 100*time.Millisecond)
+
+	invalidPath := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalidPath, []byte(`{"requests": [`), 0644); err != nil {
+		t.Fatalf("failed to write invalid results file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		current  string
+		exitCode int
+		output   string
+	}{
+		{name: "NoRegression", current: writeResultsFile(t, dir, "same.json", 105*time.Millisecond), exitCode: ExitOK, output: "target latency p99"},
+		{name: "Regression", current: writeResultsFile(t, dir, "slow.json", 200*time.Millisecond), exitCode: ExitThresholdBreach, output: "REGRESSION"},
+		{name: "InvalidFile", current: invalidPath, exitCode: ExitConfigError},
+		{name: "MissingFile", current: filepath.Join(dir, "missing.json"), exitCode: ExitConfigError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseline, err := ReadResultsFile(baselinePath)
+			if err != nil {
+				t.Fatalf("ReadResultsFile() unexpected error: %v", err)
+			}
+
+			current, err := ReadResultsFile(tt.current)
+			if err != nil {
+				err = NewConfigError(err)
+			} else {
+				var sb strings.Builder
+				err = CompareResults(baseline, current, compare.DefaultTolerances(), &sb)
+				if !strings.Contains(sb.String(), tt.output) {
+					t.Errorf("CompareResults() output does not contain %q:\n%s", tt.output, sb.String())
+				}
+			}
+
+			if code := ExitCode(err); code != tt.exitCode {
+				t.Errorf("exit code = %d, want %d (error %v)", code, tt.exitCode, err)
+			}
+		})
+	}
+}
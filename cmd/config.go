@@ -5,8 +5,13 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"reflect"
 
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/feeder"
 	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/templating"
 )
 
 type ConfigValidationError struct {
@@ -29,7 +34,13 @@ func ParseConfigFile(filePath string) (*simulation.SimulationConfig, error) {
 	}
 	defer file.Close()
 
-	return ParseConfigReader(file, false)
+	config, err := ParseConfigReader(file, false)
+	if err != nil {
+		return nil, err
+	}
+
+	config.ResolvePaths(filepath.Dir(filePath))
+	return config, nil
 }
 
 func ParseConfigReader(reader io.Reader, strict bool) (*simulation.SimulationConfig, error) {
@@ -62,6 +73,380 @@ func ValidateConfigData(config *simulation.SimulationConfig) []ConfigValidationE
 		return errors
 	}
 
+	if err := config.Thresholds.Validate(); err != nil {
+		errors = append(errors, ConfigValidationError{
+			Field:   "thresholds",
+			Message: err.Error(),
+		})
+	}
+
+	if err := config.Stages.Validate(); err != nil {
+		errors = append(errors, ConfigValidationError{
+			Field:   "stages",
+			Message: err.Error(),
+		})
+	}
+
+	if config.Cadence < 0 {
+		errors = append(errors, ConfigValidationError{
+			Field:   "cadence",
+			Message: fmt.Sprintf("cadence can not be negative, got %s", config.Cadence),
+		})
+	}
+
+	if config.Duration == 0 && len(config.Stages) == 0 && config.Attempts <= 0 {
+		errors = append(errors, ConfigValidationError{
+			Field:   "attempts",
+			Message: fmt.Sprintf("attempts must be greater than 0 without a duration or stages, got %d", config.Attempts),
+		})
+	}
+
+	if len(config.Stages) > 0 && (config.Attempts != 0 || config.Cadence != 0) {
+		errors = append(errors, ConfigValidationError{
+			Field:   "stages",
+			Message: "stages can not be combined with attempts or cadence",
+		})
+	}
+
+	errors = append(errors, validateDurationConfig(config)...)
+	errors = append(errors, validateArrivalConfig(config)...)
+	errors = append(errors, validateDataConfig(config.Data)...)
+
+	if len(config.Targets) == 0 {
+		return append(errors, validateTargetConfig("target", &config.Target)...)
+	}
+
+	if !reflect.ValueOf(config.Target).IsZero() {
+		errors = append(errors, ConfigValidationError{
+			Field:   "targets",
+			Message: "targets can not be combined with target",
+		})
+	}
+
+	weighted := 0
+	for i, target := range config.Targets {
+		field := fmt.Sprintf("targets[%d]", i)
+		if target == nil {
+			errors = append(errors, ConfigValidationError{
+				Field:   field,
+				Message: "target can not be empty",
+			})
+			continue
+		}
+
+		if target.Weight < 0 {
+			errors = append(errors, ConfigValidationError{
+				Field:   field + ".weight",
+				Message: fmt.Sprintf("weight can not be negative, got %d", target.Weight),
+			})
+		}
+
+		if target.Weight > 0 {
+			weighted++
+		}
+
+		errors = append(errors, validateTargetConfig(field, target)...)
+	}
+
+	if weighted > 0 && weighted != len(config.Targets) {
+		errors = append(errors, ConfigValidationError{
+			Field:   "targets",
+			Message: "either every target must have a weight or none of them",
+		})
+	}
+
+	return errors
+}
+
+func validateDurationConfig(config *simulation.SimulationConfig) []ConfigValidationError {
+	var errors []ConfigValidationError
+	if config.Duration < 0 {
+		errors = append(errors, ConfigValidationError{
+			Field:   "duration",
+			Message: fmt.Sprintf("duration can not be negative, got %s", config.Duration),
+		})
+	}
+
+	if config.GracePeriod < 0 {
+		errors = append(errors, ConfigValidationError{
+			Field:   "gracePeriod",
+			Message: fmt.Sprintf("gracePeriod can not be negative, got %s", config.GracePeriod),
+		})
+	}
+
+	if config.Duration == 0 && config.GracePeriod != 0 {
+		errors = append(errors, ConfigValidationError{
+			Field:   "gracePeriod",
+			Message: "gracePeriod only applies to a simulation with a duration",
+		})
+	}
+
+	if config.Duration != 0 && (config.Attempts != 0 || len(config.Stages) > 0) {
+		errors = append(errors, ConfigValidationError{
+			Field:   "duration",
+			Message: "duration can not be combined with attempts or stages",
+		})
+	}
+
+	return errors
+}
+
+func validateArrivalConfig(config *simulation.SimulationConfig) []ConfigValidationError {
+	arrival := config.Arrival
+	if arrival == nil {
+		return nil
+	}
+
+	var errors []ConfigValidationError
+	if err := arrival.Validate(); err != nil {
+		errors = append(errors, ConfigValidationError{
+			Field:   "arrival",
+			Message: err.Error(),
+		})
+	}
+
+	if config.Cadence != 0 {
+		errors = append(errors, ConfigValidationError{
+			Field:   "arrival",
+			Message: "arrival can not be combined with cadence, the rate sets when iterations start",
+		})
+	}
+
+	switch {
+	case len(config.Stages) > 0 && !config.Stages.ByRate():
+		errors = append(errors, ConfigValidationError{
+			Field:   "arrival",
+			Message: "arrival needs stages that set a rate rather than a target",
+		})
+	case len(config.Stages) > 0 && arrival.Rate != 0:
+		errors = append(errors, ConfigValidationError{
+			Field:   "arrival.rate",
+			Message: "rate can not be combined with stages, the stages set the rate",
+		})
+	case len(config.Stages) == 0 && arrival.Rate <= 0:
+		errors = append(errors, ConfigValidationError{
+			Field:   "arrival.rate",
+			Message: "rate must be greater than 0 without stages",
+		})
+	}
+
+	return errors
+}
+
+func validateDataConfig(data feeder.Sources) []ConfigValidationError {
+	var errors []ConfigValidationError
+
+	for _, name := range []string{simulation.ClientVar, simulation.AttemptVar, simulation.IterationVar} {
+		if _, ok := data[name]; ok {
+			errors = append(errors, ConfigValidationError{
+				Field:   "data." + name,
+				Message: fmt.Sprintf("%q is already set for every request and can not be used for data", name),
+			})
+		}
+	}
+
+	if err := data.Validate(); err != nil {
+		return append(errors, ConfigValidationError{
+			Field:   "data",
+			Message: err.Error(),
+		})
+	}
+
+	if _, err := data.Load(); err != nil {
+		errors = append(errors, ConfigValidationError{
+			Field:   "data",
+			Message: err.Error(),
+		})
+	}
+
+	return errors
+}
+
+func validateTargetConfig(field string, target *simulation.SimulationTargetConfig) []ConfigValidationError {
+	var errors []ConfigValidationError
+	if target.Count <= 0 {
+		errors = append(errors, ConfigValidationError{
+			Field:   field + ".count",
+			Message: fmt.Sprintf("count must be greater than 0, got %d", target.Count),
+		})
+	}
+
+	if err := target.ThinkTime.Validate(); err != nil {
+		errors = append(errors, ConfigValidationError{
+			Field:   field + ".thinkTime",
+			Message: err.Error(),
+		})
+	}
+
+	if len(target.Steps) == 0 && target.Client == nil && target.Monitor == nil {
+		return append(errors, ConfigValidationError{
+			Field:   field,
+			Message: "target must have a client or a monitor",
+		})
+	}
+
+	if len(target.Steps) == 0 {
+		return append(errors, validateStepConfig(field, &simulation.SimulationStepConfig{
+			Client:  target.Client,
+			Extract: target.Extract,
+			Monitor: target.Monitor,
+		})...)
+	}
+
+	if target.Client != nil || target.Extract != nil || target.Monitor != nil {
+		errors = append(errors, ConfigValidationError{
+			Field:   field + ".steps",
+			Message: "steps can not be combined with client, extract or monitor on the target",
+		})
+	}
+
+	for i, step := range target.Steps {
+		stepField := fmt.Sprintf("%s.steps[%d]", field, i)
+		if step == nil || (step.Client == nil && step.Monitor == nil) {
+			errors = append(errors, ConfigValidationError{
+				Field:   stepField + ".client",
+				Message: "every step must have a client or a monitor",
+			})
+			continue
+		}
+
+		errors = append(errors, validateStepConfig(stepField, step)...)
+	}
+
+	return errors
+}
+
+func validateStepConfig(field string, step *simulation.SimulationStepConfig) []ConfigValidationError {
+	errors := validateClientConfig(field+".client", step.Client, false)
+
+	if err := step.Extract.Validate(); err != nil {
+		errors = append(errors, ConfigValidationError{
+			Field:   field + ".extract",
+			Message: err.Error(),
+		})
+	}
+
+	if err := step.Assert.Validate(); err != nil {
+		errors = append(errors, ConfigValidationError{
+			Field:   field + ".assert",
+			Message: err.Error(),
+		})
+	}
+
+	if step.Client == nil && (len(step.Extract) > 0 || step.Assert != nil) {
+		errors = append(errors, ConfigValidationError{
+			Field:   field + ".client",
+			Message: "extract and assert need a client to send a request",
+		})
+	}
+
+	if step.Monitor != nil {
+		if len(step.Monitor.MonitorTargets) == 0 {
+			errors = append(errors, ConfigValidationError{
+				Field:   field + ".monitor.monitorTargets",
+				Message: "monitor must have at least one target",
+			})
+		}
+
+		for i, target := range step.Monitor.MonitorTargets {
+			monitorField := fmt.Sprintf("%s.monitor.monitorTargets[%d]", field, i)
+			if target == nil {
+				errors = append(errors, ConfigValidationError{
+					Field:   monitorField,
+					Message: "monitor target can not be empty",
+				})
+				continue
+			}
+
+			errors = append(errors, validateClientConfig(monitorField+".client", target.Client, true)...)
+
+			if target.Freq <= 0 {
+				errors = append(errors, ConfigValidationError{
+					Field:   monitorField + ".freq",
+					Message: fmt.Sprintf("freq must be greater than 0, got %s", target.Freq),
+				})
+			}
+
+			if target.Retries <= 0 {
+				errors = append(errors, ConfigValidationError{
+					Field:   monitorField + ".retries",
+					Message: fmt.Sprintf("retries must be greater than 0, got %d", target.Retries),
+				})
+			}
+		}
+	}
+
+	return errors
+}
+
+// validateClientConfig checks client, which can only be left out when it is
+// not required.
+func validateClientConfig(field string, client *api.ClientConfig, required bool) []ConfigValidationError {
+	var errors []ConfigValidationError
+
+	if client == nil {
+		if required {
+			errors = append(errors, ConfigValidationError{
+				Field:   field,
+				Message: "client is required",
+			})
+		}
+		return errors
+	}
+
+	if client.Method != "" && !api.ValidMethod(client.Method) {
+		errors = append(errors, ConfigValidationError{
+			Field:   field + ".method",
+			Message: fmt.Sprintf("%q is not a valid HTTP method", client.Method),
+		})
+	}
+
+	if err := templating.Validate(client.Url); err != nil {
+		errors = append(errors, ConfigValidationError{
+			Field:   field + ".url",
+			Message: err.Error(),
+		})
+	}
+
+	for k, v := range client.Headers {
+		if err := templating.Validate(v); err != nil {
+			errors = append(errors, ConfigValidationError{
+				Field:   field + ".headers." + k,
+				Message: err.Error(),
+			})
+		}
+	}
+
+	if _, err := api.NewAuthenticator(client.Auth); err != nil {
+		errors = append(errors, ConfigValidationError{
+			Field:   field + ".auth",
+			Message: err.Error(),
+		})
+	}
+
+	if _, err := api.NewHTTPClient(client.Transport); err != nil {
+		errors = append(errors, ConfigValidationError{
+			Field:   field + ".transport",
+			Message: err.Error(),
+		})
+	}
+
+	for k := range client.Headers {
+		if !api.ValidHeaderName(k) {
+			errors = append(errors, ConfigValidationError{
+				Field:   field + ".headers",
+				Message: fmt.Sprintf("%q is not a valid header name", k),
+			})
+		}
+	}
+
+	if err := api.NewBody(client.Body).Validate(client.ContentType); err != nil {
+		errors = append(errors, ConfigValidationError{
+			Field:   field + ".body",
+			Message: err.Error(),
+		})
+	}
+
 	return errors
 }
 
@@ -1,11 +1,18 @@
 package cmd
 
 import (
+	"encoding/json"
 	"io"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/extract"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/feeder"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/monitor"
 	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
 )
 
@@ -125,6 +132,32 @@ func TestParseConfigReader(t *testing.T) {
 			strict:      true,
 			expectError: false,
 		},
+		{
+			name:        "DurationStrings",
+			reader:      strings.NewReader(`{"cadence": "250ms", "target": {"monitor": {"monitorTargets": [{"freq": "1m30s"}]}}}`),
+			strict:      true,
+			expectError: false,
+		},
+		{
+			name:        "DurationSeconds",
+			reader:      strings.NewReader(`{"cadence": 5, "target": {"monitor": {"monitorTargets": [{"freq": 2}]}}}`),
+			strict:      true,
+			expectError: false,
+		},
+		{
+			name:        "AmbiguousCadence",
+			reader:      strings.NewReader(`{"cadence": 0.5}`),
+			strict:      true,
+			expectError: true,
+			errorMsg:    "duration 0.5 is ambiguous",
+		},
+		{
+			name:        "AmbiguousFreq",
+			reader:      strings.NewReader(`{"target": {"monitor": {"monitorTargets": [{"freq": "5"}]}}}`),
+			strict:      true,
+			expectError: true,
+			errorMsg:    "missing unit",
+		},
 	}
 
 	for _, tt := range tests {
@@ -157,6 +190,8 @@ func TestParseConfigReader(t *testing.T) {
 }
 
 func TestValidateConfigData(t *testing.T) {
+	invalidRate := 1.5
+
 	tests := []struct {
 		name           string
 		config         *simulation.SimulationConfig
@@ -168,10 +203,506 @@ func TestValidateConfigData(t *testing.T) {
 			expectedErrors: 1,
 		},
 		{
-			name:           "ValidConfig",
+			name:           "EmptyConfig",
 			config:         &simulation.SimulationConfig{},
+			expectedErrors: 3,
+		},
+		{
+			name: "NothingToRun",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target:   simulation.SimulationTargetConfig{Count: 1},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "ValidConfig",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+			},
 			expectedErrors: 0,
 		},
+		{
+			name: "MissingCount",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "MissingAttempts",
+			config: &simulation.SimulationConfig{
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "CustomMethod",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Method: "PURGE", Url: "http://localhost/test"},
+				},
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "InvalidTargetMethod",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Method: "GET POST", Url: "http://localhost/test"},
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "FormBodyMustBeObject",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count: 1,
+					Client: &api.ClientConfig{
+						Url:         "http://localhost/test",
+						ContentType: "application/x-www-form-urlencoded",
+						Body:        json.RawMessage(`[1, 2, 3]`),
+					},
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "MissingBodyFile",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count: 1,
+					Client: &api.ClientConfig{
+						Url:         "http://localhost/test",
+						ContentType: "application/json",
+						Body:        json.RawMessage(`"@does-not-exist.json"`),
+					},
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "InvalidAuthAndHeader",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count: 1,
+					Client: &api.ClientConfig{
+						Url:     "http://localhost/test",
+						Headers: map[string]string{"X Bad": "value"},
+						Auth:    &api.AuthConfig{Type: "bearer"},
+					},
+				},
+			},
+			expectedErrors: 2,
+		},
+		{
+			name: "InvalidTransport",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count: 1,
+					Client: &api.ClientConfig{
+						Url:       "https://localhost/test",
+						Transport: &api.TransportConfig{Resolve: []string{"localhost:443"}},
+					},
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "InvalidExtractAndTemplate",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Url: "http://localhost/orders/{{.id"},
+					Extract: extract.Rules{
+						"id": {JSONPath: "id"},
+					},
+				},
+			},
+			expectedErrors: 2,
+		},
+		{
+			name: "InvalidMonitorMethod",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count: 1,
+					Monitor: &monitor.MonitorConfig{
+						MonitorTargets: []*monitor.MonitorTargetConfig{
+							{Client: &api.ClientConfig{Method: "GET/1", Url: "http://localhost/test"}, Freq: duration.OrSeconds(time.Second), Retries: 1},
+						},
+					},
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "InvalidMonitorFreq",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Cadence:  duration.OrSeconds(-time.Second),
+				Target: simulation.SimulationTargetConfig{
+					Count: 1,
+					Monitor: &monitor.MonitorConfig{
+						MonitorTargets: []*monitor.MonitorTargetConfig{
+							{Client: &api.ClientConfig{Url: "http://localhost/test"}, Retries: 1},
+						},
+					},
+				},
+			},
+			expectedErrors: 2,
+		},
+		{
+			name: "NilMonitorTarget",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count: 1,
+					Monitor: &monitor.MonitorConfig{
+						MonitorTargets: []*monitor.MonitorTargetConfig{nil},
+					},
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "MonitorTargetWithoutClient",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count: 1,
+					Monitor: &monitor.MonitorConfig{
+						MonitorTargets: []*monitor.MonitorTargetConfig{
+							{Freq: duration.OrSeconds(time.Second), Retries: 1},
+						},
+					},
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "MonitorTargetWithoutRetries",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count: 1,
+					Monitor: &monitor.MonitorConfig{
+						MonitorTargets: []*monitor.MonitorTargetConfig{
+							{Client: &api.ClientConfig{Url: "http://localhost/test"}, Freq: duration.OrSeconds(time.Second)},
+							{Client: &api.ClientConfig{Url: "http://localhost/test"}, Freq: duration.OrSeconds(time.Second), Retries: -1},
+						},
+					},
+				},
+			},
+			expectedErrors: 2,
+		},
+		{
+			name: "ValidSteps",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count: 1,
+					Steps: []*simulation.SimulationStepConfig{
+						{
+							Name:    "login",
+							Client:  &api.ClientConfig{Url: "http://localhost/login"},
+							Extract: extract.Rules{"token": {JSONPath: "$.token"}},
+							Assert:  &simulation.Assertions{Status: []int{200}},
+						},
+						{
+							Name: "create",
+							Client: &api.ClientConfig{
+								Url:  "http://localhost/orders",
+								Auth: &api.AuthConfig{Type: "bearer", Token: "{{.token}}"},
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "InvalidSteps",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+					Steps: []*simulation.SimulationStepConfig{
+						{Name: "no client"},
+						{
+							Client: &api.ClientConfig{Url: "http://localhost/test"},
+							Assert: &simulation.Assertions{
+								Status:  []int{42},
+								Headers: map[string]string{"X-Id": "{{.id"},
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: 3,
+		},
+		{
+			name: "MonitorOnly",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count: 1,
+					Monitor: &monitor.MonitorConfig{
+						Name: "health",
+						MonitorTargets: []*monitor.MonitorTargetConfig{
+							{Client: &api.ClientConfig{Url: "http://localhost/health"}, Freq: duration.OrSeconds(time.Second), Retries: 3},
+						},
+					},
+				},
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "InvalidMonitorOnlySteps",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count: 1,
+					Steps: []*simulation.SimulationStepConfig{
+						{
+							Extract: extract.Rules{"id": {JSONPath: "$.id"}},
+							Monitor: &monitor.MonitorConfig{Name: "empty"},
+						},
+					},
+				},
+			},
+			expectedErrors: 2,
+		},
+		{
+			name: "ValidWeightedTargets",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Targets: []*simulation.SimulationTargetConfig{
+					{Name: "reads", Count: 7, Weight: 70, Client: &api.ClientConfig{Method: "GET", Url: "http://localhost/orders"}},
+					{Name: "writes", Count: 3, Weight: 30, Client: &api.ClientConfig{Url: "http://localhost/orders"}},
+				},
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "InvalidTargets",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target:   simulation.SimulationTargetConfig{Count: 1},
+				Targets: []*simulation.SimulationTargetConfig{
+					{Count: 0, Weight: 70, Client: &api.ClientConfig{Url: "http://localhost/orders"}},
+					{Count: 1, Client: &api.ClientConfig{Method: "GET POST", Url: "http://localhost/orders"}},
+				},
+			},
+			expectedErrors: 4,
+		},
+		{
+			name: "InvalidThresholds",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+				Thresholds: &simulation.Thresholds{
+					MaxErrorRate: &invalidRate,
+					Latency:      map[string]duration.Duration{"p95": duration.Duration(time.Second), "median": duration.Duration(time.Second)},
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "ValidStages",
+			config: &simulation.SimulationConfig{
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+				Stages: simulation.Stages{
+					{Duration: duration.Duration(time.Minute), Target: 20},
+					{Duration: duration.Duration(10 * time.Minute), Target: 20},
+					{Duration: duration.Duration(time.Minute)},
+				},
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "InvalidStages",
+			config: &simulation.SimulationConfig{
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+				Attempts: 3,
+				Stages: simulation.Stages{
+					{Duration: duration.Duration(time.Minute), Target: 20},
+					{Duration: duration.Duration(time.Minute), Rate: 5},
+				},
+			},
+			expectedErrors: 2,
+		},
+		{
+			name: "ValidArrival",
+			config: &simulation.SimulationConfig{
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+				Attempts: 100,
+				Arrival:  &simulation.Arrival{Rate: 50, Distribution: simulation.ArrivalPoisson, MaxInFlight: 20},
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "ValidArrivalStages",
+			config: &simulation.SimulationConfig{
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+				Stages: simulation.Stages{
+					{Duration: duration.Duration(time.Minute), Rate: 50},
+				},
+				Arrival: &simulation.Arrival{MaxInFlight: 20},
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "InvalidArrival",
+			config: &simulation.SimulationConfig{
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+				Attempts: 100,
+				Cadence:  1,
+				Arrival:  &simulation.Arrival{Distribution: "normal"},
+			},
+			expectedErrors: 3,
+		},
+		{
+			name: "InvalidArrivalStages",
+			config: &simulation.SimulationConfig{
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+				Stages: simulation.Stages{
+					{Duration: duration.Duration(time.Minute), Target: 20},
+				},
+				Arrival: &simulation.Arrival{Rate: 50},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "InvalidThinkTime",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count:     1,
+					ThinkTime: &simulation.ThinkTime{Min: duration.Duration(3 * time.Second), Max: duration.Duration(time.Second)},
+					Client:    &api.ClientConfig{Url: "http://localhost/test"},
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "ValidDuration",
+			config: &simulation.SimulationConfig{
+				Target: simulation.SimulationTargetConfig{
+					Count:  10,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+				Duration:    duration.Duration(10 * time.Minute),
+				GracePeriod: duration.Duration(time.Minute),
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "DurationWithCadence",
+			config: &simulation.SimulationConfig{
+				Target: simulation.SimulationTargetConfig{
+					Count:  10,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+				Cadence:  duration.OrSeconds(time.Second),
+				Duration: duration.Duration(10 * time.Minute),
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "InvalidDuration",
+			config: &simulation.SimulationConfig{
+				Target: simulation.SimulationTargetConfig{
+					Count:  10,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+				Attempts:    5,
+				Duration:    duration.Duration(10 * time.Minute),
+				GracePeriod: duration.Duration(-time.Second),
+			},
+			expectedErrors: 2,
+		},
+		{
+			name: "GracePeriodWithoutDuration",
+			config: &simulation.SimulationConfig{
+				Target: simulation.SimulationTargetConfig{
+					Count:  10,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+				Attempts:    5,
+				GracePeriod: duration.Duration(time.Minute),
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "InvalidData",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+				Data: feeder.Sources{
+					"client": {File: "clients.csv"},
+					"users":  {File: "users.csv", Mode: "shuffled"},
+				},
+			},
+			expectedErrors: 2,
+		},
+		{
+			name: "MissingDataFile",
+			config: &simulation.SimulationConfig{
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count:  1,
+					Client: &api.ClientConfig{Url: "http://localhost/test"},
+				},
+				Data: feeder.Sources{"users": {File: "does-not-exist.csv"}},
+			},
+			expectedErrors: 1,
+		},
 	}
 
 	for _, tt := range tests {
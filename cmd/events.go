@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/events"
+)
+
+const (
+	EventsFormatNDJSON = "ndjson"
+
+	// EventsStdout is the --events path that streams events to stdout.
+	EventsStdout = "-"
+)
+
+// EventsSpec is an event stream requested with --events format=path.
+type EventsSpec struct {
+	Format string
+	Path   string
+}
+
+// ParseEventsSpec parses an --events value of the form format=path. It
+// returns nil when value is empty.
+func ParseEventsSpec(value string) (*EventsSpec, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	format, path, ok := strings.Cut(value, "=")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("invalid events %q, expected format=path such as ndjson=events.ndjson or ndjson=-", value)
+	}
+
+	if format != EventsFormatNDJSON {
+		return nil, fmt.Errorf("unsupported events format %q, expected %s", format, EventsFormatNDJSON)
+	}
+
+	return &EventsSpec{Format: format, Path: path}, nil
+}
+
+// OpenEvents opens the event stream of spec, writing to stdout when its path
+// is "-". The returned close func must be called once the run is over.
+func OpenEvents(spec *EventsSpec, stdout io.Writer) (*events.Emitter, func() error, error) {
+	if spec == nil {
+		return nil, func() error { return nil }, nil
+	}
+
+	if spec.Path == EventsStdout {
+		return events.NewEmitter(events.NewNDJSONWriter(stdout)), func() error { return nil }, nil
+	}
+
+	file, err := os.Create(spec.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open events file %s: %w", spec.Path, err)
+	}
+
+	return events.NewEmitter(events.NewNDJSONWriter(file)), file.Close, nil
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEventsSpec(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    *EventsSpec
+		expectError bool
+	}{
+		{name: "None", value: "", expected: nil},
+		{name: "File", value: "ndjson=events.ndjson", expected: &EventsSpec{Format: EventsFormatNDJSON, Path: "events.ndjson"}},
+		{name: "Stdout", value: "ndjson=-", expected: &EventsSpec{Format: EventsFormatNDJSON, Path: EventsStdout}},
+		{name: "MissingPath", value: "ndjson", expectError: true},
+		{name: "UnknownFormat", value: "csv=events.csv", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseEventsSpec(tt.value)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("ParseEventsSpec(%q) expected error but got none", tt.value)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseEventsSpec(%q) unexpected error: %v", tt.value, err)
+			}
+			if (spec == nil) != (tt.expected == nil) || (spec != nil && *spec != *tt.expected) {
+				t.Errorf("ParseEventsSpec(%q) = %+v, want %+v", tt.value, spec, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOpenEventsStdout(t *testing.T) {
+	var sb strings.Builder
+	emitter, closeEvents, err := OpenEvents(&EventsSpec{Format: EventsFormatNDJSON, Path: EventsStdout}, &sb)
+	if err != nil {
+		t.Fatalf("OpenEvents() unexpected error: %v", err)
+	}
+
+	emitter.Emit("test", nil)
+	if err := closeEvents(); err != nil {
+		t.Fatalf("close unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(sb.String(), `{"type":"test"`) || !strings.HasSuffix(sb.String(), "}\n") {
+		t.Errorf("OpenEvents() wrote %q, want a single json line", sb.String())
+	}
+}
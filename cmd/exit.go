@@ -0,0 +1,57 @@
+package cmd
+
+import "errors"
+
+// Exit codes returned by easy-test, so CI pipelines can tell a failing
+// service apart from a broken configuration.
+const (
+	ExitOK              = 0
+	ExitRuntimeError    = 1
+	ExitThresholdBreach = 2
+	ExitConfigError     = 3
+)
+
+// ExitError is an error that makes easy-test exit with Code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+func NewConfigError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &ExitError{Code: ExitConfigError, Err: err}
+}
+
+func NewThresholdError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &ExitError{Code: ExitThresholdBreach, Err: err}
+}
+
+// ExitCode returns the code easy-test should exit with after err. Errors that
+// are not an ExitError are runtime errors.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	return ExitRuntimeError
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/report"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+)
+
+const (
+	ReportFormatJUnit = "junit"
+	ReportFormatHTML  = "html"
+	ReportFormatJSON  = "json"
+)
+
+// ReportSpec is a report requested with --report format=path.
+type ReportSpec struct {
+	Format string
+	Path   string
+}
+
+// ParseReportSpecs parses --report values of the form format=path.
+func ParseReportSpecs(values []string) ([]ReportSpec, error) {
+	specs := make([]ReportSpec, 0, len(values))
+	for _, value := range values {
+		format, path, ok := strings.Cut(value, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid report %q, expected format=path such as junit=report.xml", value)
+		}
+
+		switch format {
+		case ReportFormatJUnit, ReportFormatHTML, ReportFormatJSON:
+		default:
+			return nil, fmt.Errorf("unsupported report format %q, expected %s, %s or %s", format, ReportFormatJUnit, ReportFormatHTML, ReportFormatJSON)
+		}
+
+		specs = append(specs, ReportSpec{Format: format, Path: path})
+	}
+
+	return specs, nil
+}
+
+// WriteReports writes every requested report of results.
+func WriteReports(specs []ReportSpec, results []*simulation.SimulationResult) error {
+	for _, spec := range specs {
+		if err := writeReport(spec, results); err != nil {
+			return fmt.Errorf("failed to write %s report to %s: %w", spec.Format, spec.Path, err)
+		}
+	}
+
+	return nil
+}
+
+func writeReport(spec ReportSpec, results []*simulation.SimulationResult) error {
+	file, err := os.Create(spec.Path)
+	if err != nil {
+		return err
+	}
+
+	var write func(io.Writer, []*simulation.SimulationResult) error
+	switch spec.Format {
+	case ReportFormatJUnit:
+		write = report.WriteJUnit
+	case ReportFormatHTML:
+		write = report.WriteHTML
+	case ReportFormatJSON:
+		write = report.WriteJSON
+	default:
+		file.Close()
+		return fmt.Errorf("unsupported report format %q", spec.Format)
+	}
+
+	if err := write(file, results); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+)
+
+func TestParseReportSpecs(t *testing.T) {
+	tests := []struct {
+		name        string
+		values      []string
+		expected    []ReportSpec
+		expectError bool
+	}{
+		{name: "None", values: nil, expected: []ReportSpec{}},
+		{name: "JUnit", values: []string{"junit=out/report.xml"}, expected: []ReportSpec{{Format: ReportFormatJUnit, Path: "out/report.xml"}}},
+		{name: "Several", values: []string{"junit=report.xml", "html=report.html", "json=results.json"}, expected: []ReportSpec{{Format: ReportFormatJUnit, Path: "report.xml"}, {Format: ReportFormatHTML, Path: "report.html"}, {Format: ReportFormatJSON, Path: "results.json"}}},
+		{name: "MissingPath", values: []string{"junit="}, expectError: true},
+		{name: "MissingFormat", values: []string{"report.xml"}, expectError: true},
+		{name: "UnknownFormat", values: []string{"pdf=report.pdf"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, err := ParseReportSpecs(tt.values)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("ParseReportSpecs(%v) expected error but got none", tt.values)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseReportSpecs(%v) unexpected error: %v", tt.values, err)
+			}
+			if len(specs) != len(tt.expected) {
+				t.Fatalf("ParseReportSpecs(%v) = %v, want %v", tt.values, specs, tt.expected)
+			}
+			for i := range specs {
+				if specs[i] != tt.expected[i] {
+					t.Errorf("ParseReportSpecs(%v)[%d] = %v, want %v", tt.values, i, specs[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteSimulationReports(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusServiceUnavailable)
+		res.Write([]byte("maintenance"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.xml")
+	htmlPath := filepath.Join(dir, "report.html")
+	jsonPath := filepath.Join(dir, "report.json")
+	opts := SimulationOptions{
+		Config: &simulation.SimulationConfig{
+			Name:     "Reported",
+			Attempts: 1,
+			Target: simulation.SimulationTargetConfig{
+				Count: 1,
+				Client: &api.ClientConfig{
+					Method:  "GET",
+					Url:     server.URL,
+					Headers: map[string]string{"X-Service-Token": "top-secret-header"},
+					Auth:    &api.AuthConfig{Type: "bearer", Token: "top-secret-token"},
+				},
+			},
+		},
+		Workers: 1,
+		Timeout: 5 * time.Second,
+		Out:     &bytes.Buffer{},
+		Reports: []ReportSpec{{Format: ReportFormatJUnit, Path: path}, {Format: ReportFormatHTML, Path: htmlPath}, {Format: ReportFormatJSON, Path: jsonPath}},
+	}
+
+	if err := ExecuteSimulation(opts, slog.Default()); ExitCode(err) != ExitThresholdBreach {
+		t.Fatalf("ExecuteSimulation() error = %v, want the default thresholds breached", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("report was not written: %v", err)
+	}
+
+	for _, want := range []string{`<testsuite name="Reported"`, `failures="2"`, "threshold errorRate", "unexpected status 503", "response: maintenance"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("report does not contain %q:\n%s", want, data)
+		}
+	}
+
+	html, err := os.ReadFile(htmlPath)
+	if err != nil {
+		t.Fatalf("html report was not written: %v", err)
+	}
+	if !strings.Contains(string(html), "503 Service Unavailable") || !strings.Contains(string(html), "maintenance") {
+		t.Errorf("html report does not show the failed request:\n%s", html)
+	}
+	jsonReport, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("json report was not written: %v", err)
+	}
+	for _, report := range [][]byte{html, jsonReport} {
+		if bytes.Contains(report, []byte("top-secret")) || !bytes.Contains(report, []byte(api.Redacted)) {
+			t.Errorf("report does not redact the credentials of the config:\n%s", report)
+		}
+	}
+}
@@ -29,7 +29,7 @@ and monitor your services with detailed logging and observability features.`,
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(ExitCode(err))
 	}
 }
 
@@ -44,6 +44,9 @@ func init() {
 		"disable colored output")
 
 	rootCmd.SetHelpCommand(&cobra.Command{Hidden: true})
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return NewConfigError(err)
+	})
 }
 
 func GetVerbose() bool {
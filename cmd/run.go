@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"fmt"
 	"log/slog"
+	"os"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -12,24 +14,50 @@ import (
 var (
 	simulationPath string
 	dryRun         bool
+	planFormat     string
 	workers        int
 	timeout        time.Duration
+	reports        []string
+	eventsOutput   string
+	tags           []string
+	skipTags       []string
+	parallel       int
 )
 
 var runCmd = &cobra.Command{
-	Use:   "run [simulation-file]",
+	Use:   "run [simulation-file|directory]",
 	Short: "Execute a simulation",
 	Long: `Run executes a simulation configuration file with optional dry-run mode.
 
 The simulation file should contain a JSON configuration that defines the
 test scenarios, endpoints, and parameters for your integration tests.
 
+Given a directory, run executes every .json simulation file in it and its
+subdirectories as a suite. --tag and --skip-tag pick simulations by their
+tags, --parallel sets how many run at once, and reports combine the results
+of every simulation.
+
 Examples:
   easy-test run
   easy-test run simulation.json
   easy-test run --dry
+  easy-test run --dry --plan-format json simulation.json
   easy-test run --path custom.json --workers 20
-  easy-test run --timeout 5m custom-simulation.json`,
+  easy-test run --timeout 5m custom-simulation.json
+  easy-test run --report junit=report.xml simulation.json
+  easy-test run --report junit=report.xml --report html=report.html
+  easy-test run --events ndjson=- simulation.json
+  easy-test run ./simulations/
+  easy-test run --tag smoke --skip-tag slow --parallel 4 ./simulations/
+
+Without a thresholds block a simulation passes only when no request failed
+and every monitor was satisfied.
+
+Exit codes:
+  0  the simulation ran and every threshold passed
+  1  runtime error, including hitting the timeout
+  2  one or more thresholds were breached
+  3  invalid configuration or flags`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runSimulation,
 }
@@ -40,24 +68,54 @@ func init() {
 	runCmd.Flags().StringVarP(&simulationPath, "path", "p", "simulation.json",
 		"path to simulation configuration file")
 	runCmd.Flags().BoolVar(&dryRun, "dry", false,
-		"dry run without making external requests")
+		"dry run without making external requests, printing the execution plan instead")
+	runCmd.Flags().StringVar(&planFormat, "plan-format", PlanFormatText,
+		"format of the dry run plan (text, json)")
 	runCmd.Flags().IntVarP(&workers, "workers", "w", 10,
 		"number of concurrent workers")
 	runCmd.Flags().DurationVarP(&timeout, "timeout", "t", 30*time.Second,
 		"simulation timeout")
+	runCmd.Flags().StringArrayVar(&reports, "report", nil,
+		"write a report after the run as format=path (junit, html, json), may be repeated")
+	runCmd.Flags().StringVar(&eventsOutput, "events", "",
+		"stream run events as format=path (ndjson), use - for stdout and the summary moves to stderr")
+	runCmd.Flags().StringArrayVar(&tags, "tag", nil,
+		"when running a directory, only run simulations with this tag, may be repeated")
+	runCmd.Flags().StringArrayVar(&skipTags, "skip-tag", nil,
+		"when running a directory, skip simulations with this tag, may be repeated")
+	runCmd.Flags().IntVar(&parallel, "parallel", 1,
+		"when running a directory, number of simulations to run at once")
 }
 
 func runSimulation(cmd *cobra.Command, args []string) error {
 	configPath := ResolveConfigPath(args, simulationPath)
 
+	if planFormat != PlanFormatText && planFormat != PlanFormatJSON {
+		return NewConfigError(fmt.Errorf("unsupported plan format %q, expected %s or %s", planFormat, PlanFormatText, PlanFormatJSON))
+	}
+
+	reportSpecs, err := ParseReportSpecs(reports)
+	if err != nil {
+		return NewConfigError(err)
+	}
+
+	eventsSpec, err := ParseEventsSpec(eventsOutput)
+	if err != nil {
+		return NewConfigError(err)
+	}
+
+	if info, err := os.Stat(configPath); err == nil && info.IsDir() {
+		return runSuite(cmd, configPath, reportSpecs, eventsSpec)
+	}
+
 	config, err := ParseConfigFile(configPath)
 	if err != nil {
-		return err
+		return NewConfigError(err)
 	}
 
 	validationErrors := ValidateConfigData(config)
 	if len(validationErrors) > 0 {
-		return FormatValidationErrors(validationErrors)
+		return NewConfigError(FormatValidationErrors(validationErrors))
 	}
 
 	loggerOpts := CreateLoggerOptions(GetVerbose(), GetNoColor(), GetLogLevel(), "lightGreen")
@@ -65,13 +123,85 @@ func runSimulation(cmd *cobra.Command, args []string) error {
 	log := ConfigureProcessLogger(baseLogger, "Simulation", "CLI", dryRun)
 
 	simOpts, err := PrepareSimulationOptions(config, dryRun, workers, timeout)
+	if err != nil {
+		return NewConfigError(err)
+	}
+	simOpts.PlanFormat = planFormat
+	simOpts.Out = cmd.OutOrStdout()
+	simOpts.Reports = reportSpecs
+
+	if dryRun {
+		return ExecuteSimulation(simOpts, log)
+	}
+
+	emitter, closeEvents, err := OpenEvents(eventsSpec, cmd.OutOrStdout())
 	if err != nil {
 		return err
 	}
+	defer closeEvents()
+
+	if eventsSpec != nil && eventsSpec.Path == EventsStdout {
+		simOpts.Out = cmd.ErrOrStderr()
+	}
+	simOpts.Events = emitter
 
 	return ExecuteSimulation(simOpts, log)
 }
 
+func runSuite(cmd *cobra.Command, dir string, reportSpecs []ReportSpec, eventsSpec *EventsSpec) error {
+	if parallel <= 0 {
+		return NewConfigError(fmt.Errorf("parallel must be greater than 0, got %d", parallel))
+	}
+
+	files, err := LoadSuite(dir, tags, skipTags)
+	if err != nil {
+		return NewConfigError(err)
+	}
+
+	loggerOpts := CreateLoggerOptions(GetVerbose(), GetNoColor(), GetLogLevel(), "lightGreen")
+	baseLogger := CreateLogger(loggerOpts)
+	log := ConfigureProcessLogger(baseLogger, "Suite", "CLI", dryRun)
+
+	suiteOpts := SuiteOptions{
+		Simulations: make([]SimulationOptions, 0, len(files)),
+		Parallel:    parallel,
+		Out:         cmd.OutOrStdout(),
+		Reports:     reportSpecs,
+	}
+
+	for _, file := range files {
+		simOpts, err := PrepareSimulationOptions(file.Config, dryRun, workers, timeout)
+		if err != nil {
+			return NewConfigError(fmt.Errorf("%s: %w", file.Path, err))
+		}
+		simOpts.PlanFormat = planFormat
+		simOpts.Out = suiteOpts.Out
+
+		log.Info("Adding simulation to suite", "file", file.Path, "simulation", file.Config.Name)
+		suiteOpts.Simulations = append(suiteOpts.Simulations, simOpts)
+	}
+
+	if dryRun {
+		return ExecuteSuite(suiteOpts, log)
+	}
+
+	emitter, closeEvents, err := OpenEvents(eventsSpec, cmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+	defer closeEvents()
+
+	if eventsSpec != nil && eventsSpec.Path == EventsStdout {
+		suiteOpts.Out = cmd.ErrOrStderr()
+	}
+	for i := range suiteOpts.Simulations {
+		suiteOpts.Simulations[i].Out = suiteOpts.Out
+		suiteOpts.Simulations[i].Events = emitter
+	}
+
+	return ExecuteSuite(suiteOpts, log)
+}
+
 func parseLogLevel(level string) slog.Level {
 	switch level {
 	case "trace":
@@ -1,18 +1,33 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/Easy-Infra-Ltd/easy-test/internal/events"
 	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/threadpool"
+)
+
+const (
+	PlanFormatText = "text"
+	PlanFormatJSON = "json"
 )
 
 type SimulationOptions struct {
-	Config  *simulation.SimulationConfig
-	DryRun  bool
-	Workers int
-	Timeout time.Duration
+	Config     *simulation.SimulationConfig
+	DryRun     bool
+	PlanFormat string
+	Workers    int
+	Timeout    time.Duration
+	Out        io.Writer
+	Reports    []ReportSpec
+	Events     *events.Emitter
 }
 
 func PrepareSimulationOptions(config *simulation.SimulationConfig, dryRun bool, workers int, timeout time.Duration) (SimulationOptions, error) {
@@ -28,6 +43,16 @@ func PrepareSimulationOptions(config *simulation.SimulationConfig, dryRun bool,
 		return SimulationOptions{}, fmt.Errorf("timeout must be greater than 0, got %v", timeout)
 	}
 
+	if clients := config.Concurrency(); clients > threadpool.MAX_WORKERS {
+		return SimulationOptions{}, fmt.Errorf("simulation keeps %d client(s) iterating at once but at most %d can run at once", clients, threadpool.MAX_WORKERS)
+	} else if clients > workers {
+		return SimulationOptions{}, fmt.Errorf("simulation keeps %d client(s) iterating at once but there are only %d workers, raise --workers to at least %d", clients, workers, clients)
+	}
+
+	if runTime := config.RunTime(); !dryRun && runTime > timeout {
+		return SimulationOptions{}, fmt.Errorf("simulation runs for up to %v but the timeout is %v, raise the timeout", runTime, timeout)
+	}
+
 	return SimulationOptions{
 		Config:  config,
 		DryRun:  dryRun,
@@ -48,9 +73,115 @@ func ExecuteSimulation(opts SimulationOptions, logger *slog.Logger) error {
 			"timeout", opts.Timeout)
 	}
 
-	sim := simulation.NewSimulationFromConfig(opts.Config, opts.DryRun)
-	sim.Start()
+	if opts.DryRun {
+		sim, err := simulation.NewSimulationFromConfig(opts.Config, opts.Workers, opts.DryRun)
+		if err != nil {
+			return NewConfigError(err)
+		}
+
+		return WritePlan(opts.Out, sim.Plan(), opts.PlanFormat)
+	}
+
+	result, err := RunSimulation(opts, logger)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteSummary(opts.Out, result); err != nil {
+		return err
+	}
+
+	if err := WriteReports(opts.Reports, []*simulation.SimulationResult{result}); err != nil {
+		return err
+	}
+
+	return SimulationOutcome(result, opts.Timeout, logger)
+}
+
+// RunSimulation runs the simulation described by opts until it finishes or
+// opts.Timeout passes, and checks its thresholds, or the default thresholds
+// when the config sets none.
+func RunSimulation(opts SimulationOptions, logger *slog.Logger) (*simulation.SimulationResult, error) {
+	sim, err := simulation.NewSimulationFromConfig(opts.Config, opts.Workers, opts.DryRun)
+	if err != nil {
+		return nil, NewConfigError(err)
+	}
+
+	ctx, cancel := context.WithTimeout(events.NewContext(context.Background(), opts.Events), opts.Timeout)
+	defer cancel()
+
+	result := sim.Start(ctx)
+	result.Config = opts.Config.Redacted()
+	summary := result.Summary
+
+	logger.Info("Simulation finished",
+		"duration", result.Duration,
+		"iterations", summary.Iterations,
+		"failedIterations", summary.FailedIterations,
+		"requests", summary.Requests,
+		"failedRequests", summary.FailedRequests,
+		"monitors", summary.Monitors,
+		"failedMonitors", summary.FailedMonitors)
+
+	thresholds := opts.Config.Thresholds
+	if thresholds == nil {
+		thresholds = simulation.DefaultThresholds()
+	}
+	result.CheckThresholds(thresholds)
+
+	return result, nil
+}
+
+// SimulationOutcome returns the error a finished run should end with, if it
+// timed out or breached a threshold.
+func SimulationOutcome(result *simulation.SimulationResult, timeout time.Duration, logger *slog.Logger) error {
+	if result.TimedOut {
+		return fmt.Errorf("simulation timed out after %v", timeout)
+	}
+
+	if !result.ThresholdsPassed() {
+		var breached []string
+		for _, threshold := range result.Thresholds {
+			if !threshold.Passed {
+				logger.Error("Threshold breached", "threshold", threshold.Name, "actual", threshold.Actual, "limit", threshold.Limit)
+				breached = append(breached, threshold.String())
+			}
+		}
+
+		return NewThresholdError(fmt.Errorf("thresholds breached: %s", strings.Join(breached, "; ")))
+	}
+
+	for _, threshold := range result.Thresholds {
+		logger.Info("Threshold passed", "threshold", threshold.Name, "actual", threshold.Actual, "limit", threshold.Limit)
+	}
 
 	logger.Info("Simulation completed successfully")
 	return nil
-}
\ No newline at end of file
+}
+
+// WriteSummary writes the summary table of a finished run to out, or to stdout
+// when out is nil.
+func WriteSummary(out io.Writer, result *simulation.SimulationResult) error {
+	if out == nil {
+		out = os.Stdout
+	}
+
+	return result.WriteSummary(out)
+}
+
+// WritePlan writes a dry run plan to out, or to stdout when out is nil, as
+// text or JSON.
+func WritePlan(out io.Writer, plan *simulation.Plan, format string) error {
+	if out == nil {
+		out = os.Stdout
+	}
+
+	switch format {
+	case PlanFormatText, "":
+		return plan.WriteText(out)
+	case PlanFormatJSON:
+		return plan.WriteJSON(out)
+	default:
+		return fmt.Errorf("unsupported plan format %q, expected %s or %s", format, PlanFormatText, PlanFormatJSON)
+	}
+}
@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
 	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
 )
 
@@ -104,6 +110,90 @@ func TestPrepareSimulationOptions(t *testing.T) {
 			timeout:     24 * time.Hour,
 			expectError: false,
 		},
+		{
+			name:        "DurationWithinTimeout",
+			config:      &simulation.SimulationConfig{Duration: duration.Duration(10 * time.Minute)},
+			dryRun:      false,
+			workers:     10,
+			timeout:     15 * time.Minute,
+			expectError: false,
+		},
+		{
+			name:        "DurationPastTimeout",
+			config:      &simulation.SimulationConfig{Duration: duration.Duration(10 * time.Minute)},
+			dryRun:      false,
+			workers:     10,
+			timeout:     30 * time.Second,
+			expectError: true,
+			errorMsg:    "simulation runs for up to 10m30s but the timeout is 30s",
+		},
+		{
+			name:        "StagesWithinWorkers",
+			config:      &simulation.SimulationConfig{Stages: simulation.Stages{{Duration: duration.Duration(time.Minute), Target: 10}}},
+			dryRun:      true,
+			workers:     10,
+			timeout:     5 * time.Minute,
+			expectError: false,
+		},
+		{
+			name:        "StagesPastWorkers",
+			config:      &simulation.SimulationConfig{Stages: simulation.Stages{{Duration: duration.Duration(time.Minute), Target: 50}, {Duration: duration.Duration(time.Minute)}}},
+			dryRun:      true,
+			workers:     10,
+			timeout:     5 * time.Minute,
+			expectError: true,
+			errorMsg:    "raise --workers to at least 50",
+		},
+		{
+			name:        "StagesPastMaxWorkers",
+			config:      &simulation.SimulationConfig{Stages: simulation.Stages{{Duration: duration.Duration(time.Minute), Target: 500}}},
+			dryRun:      false,
+			workers:     1000,
+			timeout:     5 * time.Minute,
+			expectError: true,
+			errorMsg:    "at most 64 can run at once",
+		},
+		{
+			name:        "RateStagesIgnoreWorkers",
+			config:      &simulation.SimulationConfig{Stages: simulation.Stages{{Duration: duration.Duration(time.Minute), Rate: 500}}},
+			dryRun:      false,
+			workers:     10,
+			timeout:     5 * time.Minute,
+			expectError: false,
+		},
+		{
+			name:        "DurationWithinWorkers",
+			config:      &simulation.SimulationConfig{Target: simulation.SimulationTargetConfig{Count: 10}, Duration: duration.Duration(time.Minute)},
+			dryRun:      true,
+			workers:     10,
+			timeout:     5 * time.Minute,
+			expectError: false,
+		},
+		{
+			name:        "DurationPastWorkers",
+			config:      &simulation.SimulationConfig{Targets: []*simulation.SimulationTargetConfig{{Count: 8}, {Count: 4}}, Duration: duration.Duration(time.Minute)},
+			dryRun:      true,
+			workers:     10,
+			timeout:     5 * time.Minute,
+			expectError: true,
+			errorMsg:    "simulation keeps 12 client(s) iterating at once but there are only 10 workers",
+		},
+		{
+			name:        "DurationArrivalIgnoresWorkers",
+			config:      &simulation.SimulationConfig{Target: simulation.SimulationTargetConfig{Count: 100}, Duration: duration.Duration(time.Minute), Arrival: &simulation.Arrival{Rate: 10}},
+			dryRun:      true,
+			workers:     10,
+			timeout:     5 * time.Minute,
+			expectError: false,
+		},
+		{
+			name:        "DurationPastTimeoutDryRun",
+			config:      &simulation.SimulationConfig{Duration: duration.Duration(10 * time.Minute)},
+			dryRun:      true,
+			workers:     10,
+			timeout:     30 * time.Second,
+			expectError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -140,12 +230,50 @@ func TestPrepareSimulationOptions(t *testing.T) {
 }
 
 func TestExecuteSimulation(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	slowConfig := &simulation.SimulationConfig{
+		Name:     "Slow",
+		Attempts: 1,
+		Target: simulation.SimulationTargetConfig{
+			Count:  2,
+			Client: &api.ClientConfig{Url: slow.URL},
+		},
+	}
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+
+	noErrors := 0.0
+	missingConfig := &simulation.SimulationConfig{
+		Name:     "Missing",
+		Attempts: 1,
+		Target: simulation.SimulationTargetConfig{
+			Count:  2,
+			Client: &api.ClientConfig{Method: "GET", Url: missing.URL},
+		},
+		Thresholds: &simulation.Thresholds{MaxErrorRate: &noErrors},
+	}
+
+	withThresholds := func(thresholds *simulation.Thresholds) *simulation.SimulationConfig {
+		config := *missingConfig
+		config.Thresholds = thresholds
+		return &config
+	}
+
 	tests := []struct {
 		name        string
 		opts        SimulationOptions
 		logger      *slog.Logger
 		expectError bool
 		errorMsg    string
+		exitCode    int
 	}{
 		{
 			name: "NilLogger",
@@ -158,6 +286,53 @@ func TestExecuteSimulation(t *testing.T) {
 			logger:      nil,
 			expectError: true,
 			errorMsg:    "logger cannot be nil",
+			exitCode:    ExitRuntimeError,
+		},
+		{
+			name: "TimedOut",
+			opts: SimulationOptions{
+				Config:  slowConfig,
+				Workers: 1,
+				Timeout: 100 * time.Millisecond,
+			},
+			logger:      slog.Default(),
+			expectError: true,
+			errorMsg:    "simulation timed out after 100ms",
+			exitCode:    ExitRuntimeError,
+		},
+		{
+			name: "ThresholdsBreached",
+			opts: SimulationOptions{
+				Config:  missingConfig,
+				Workers: 1,
+				Timeout: 5 * time.Second,
+			},
+			logger:      slog.Default(),
+			expectError: true,
+			errorMsg:    "thresholds breached: errorRate breached: 100.00%, limit 0.00%",
+			exitCode:    ExitThresholdBreach,
+		},
+		{
+			name: "DefaultThresholdsBreached",
+			opts: SimulationOptions{
+				Config:  withThresholds(nil),
+				Workers: 1,
+				Timeout: 5 * time.Second,
+			},
+			logger:      slog.Default(),
+			expectError: true,
+			errorMsg:    "thresholds breached: errorRate breached: 100.00%, limit 0.00%",
+			exitCode:    ExitThresholdBreach,
+		},
+		{
+			name: "EmptyThresholds",
+			opts: SimulationOptions{
+				Config:  withThresholds(&simulation.Thresholds{}),
+				Workers: 1,
+				Timeout: 5 * time.Second,
+			},
+			logger:      slog.Default(),
+			expectError: false,
 		},
 	}
 
@@ -172,6 +347,9 @@ func TestExecuteSimulation(t *testing.T) {
 				if tt.errorMsg != "" && err.Error() != tt.errorMsg {
 					t.Errorf("ExecuteSimulation() error = %v, want %v", err.Error(), tt.errorMsg)
 				}
+				if code := ExitCode(err); code != tt.exitCode {
+					t.Errorf("ExitCode() = %d, want %d", code, tt.exitCode)
+				}
 			} else {
 				if err != nil {
 					t.Errorf("ExecuteSimulation() unexpected error: %v", err)
@@ -181,6 +359,69 @@ func TestExecuteSimulation(t *testing.T) {
 	}
 }
 
+func TestExecuteSimulationDryRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		t.Errorf("dry run sent %s %s", req.Method, req.URL)
+	}))
+	defer server.Close()
+
+	config := &simulation.SimulationConfig{
+		Name:     "Dry",
+		Attempts: 1,
+		Target: simulation.SimulationTargetConfig{
+			Count:  1,
+			Client: &api.ClientConfig{
+				Url:     server.URL + "/orders",
+				Headers: map[string]string{"X-Service-Token": "abc123"},
+				Auth:    &api.AuthConfig{Type: "bearer", Token: "abc123"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		format      string
+		contains    string
+		expectError bool
+	}{
+		{name: "Text", format: PlanFormatText, contains: "POST " + server.URL + "/orders"},
+		{name: "JSON", format: PlanFormatJSON, contains: `"url": "` + server.URL + `/orders"`},
+		{name: "UnknownFormat", format: "yaml", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			opts := SimulationOptions{
+				Config:     config,
+				DryRun:     true,
+				PlanFormat: tt.format,
+				Workers:    1,
+				Timeout:    time.Second,
+				Out:        &out,
+			}
+
+			err := ExecuteSimulation(opts, slog.Default())
+			if tt.expectError {
+				if err == nil {
+					t.Error("ExecuteSimulation() expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ExecuteSimulation() unexpected error: %v", err)
+			}
+			if !strings.Contains(out.String(), tt.contains) {
+				t.Errorf("plan does not contain %q:\n%s", tt.contains, out.String())
+			}
+			if strings.Contains(out.String(), "abc123") {
+				t.Errorf("plan contains a secret header value:\n%s", out.String())
+			}
+		})
+	}
+}
+
 func TestSimulationOptionsStruct(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -303,4 +544,26 @@ func BenchmarkPrepareSimulationOptionsOnly(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_, _ = PrepareSimulationOptions(config, true, 10, 30*time.Second)
 	}
-}
\ No newline at end of file
+}
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "NoError", err: nil, expected: ExitOK},
+		{name: "RuntimeError", err: errors.New("connection refused"), expected: ExitRuntimeError},
+		{name: "ConfigError", err: NewConfigError(errors.New("invalid config")), expected: ExitConfigError},
+		{name: "ThresholdError", err: NewThresholdError(errors.New("thresholds breached")), expected: ExitThresholdBreach},
+		{name: "WrappedConfigError", err: fmt.Errorf("run: %w", NewConfigError(errors.New("invalid config"))), expected: ExitConfigError},
+		{name: "NilConfigError", err: NewConfigError(nil), expected: ExitOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ExitCode(tt.err); code != tt.expected {
+				t.Errorf("ExitCode() = %d, want %d", code, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/threadpool"
+)
+
+// SuiteFile is a simulation configuration found in a suite directory.
+type SuiteFile struct {
+	Path   string
+	Config *simulation.SimulationConfig
+}
+
+// SuiteOptions configures a run of several simulations, at most Parallel of
+// them at once. Reports combine the results of every simulation.
+type SuiteOptions struct {
+	Simulations []SimulationOptions
+	Parallel    int
+	Out         io.Writer
+	Reports     []ReportSpec
+}
+
+// DiscoverSimulationFiles returns every .json file in dir and its
+// subdirectories, in lexical order. Hidden directories are skipped. Not every
+// file has to be a simulation, see LoadSuite.
+func DiscoverSimulationFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read simulation directory %q: %w", dir, err)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no simulation files found in %q", dir)
+	}
+
+	return files, nil
+}
+
+// MatchesTags reports whether a simulation with tags should run. When include
+// is not empty the simulation needs at least one of its tags, and it must not
+// have any tag in exclude.
+func MatchesTags(tags []string, include []string, exclude []string) bool {
+	for _, tag := range exclude {
+		if slices.Contains(tags, tag) {
+			return false
+		}
+	}
+
+	if len(include) == 0 {
+		return true
+	}
+
+	for _, tag := range include {
+		if slices.Contains(tags, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LoadSuite parses and validates every simulation file in dir and returns the
+// ones whose tags match include and exclude, see MatchesTags. A .json file
+// without a target or targets, such as a body fixture, is not a simulation and
+// is skipped. Simulations are parsed strictly, and relative paths in them are
+// relative to the directory of their file.
+func LoadSuite(dir string, include []string, exclude []string) ([]*SuiteFile, error) {
+	paths, err := DiscoverSimulationFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	var files []*SuiteFile
+	found := 0
+	names := make(map[string]string, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to read simulation file: %w", path, err))
+			continue
+		}
+
+		if !isSimulationFile(data) {
+			continue
+		}
+		found++
+
+		config, err := ParseConfigReader(bytes.NewReader(data), true)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		config.ResolvePaths(filepath.Dir(path))
+
+		if validationErrors := ValidateConfigData(config); len(validationErrors) > 0 {
+			errs = append(errs, fmt.Errorf("%s: %w", path, FormatValidationErrors(validationErrors)))
+			continue
+		}
+
+		if !MatchesTags(config.Tags, include, exclude) {
+			continue
+		}
+
+		if other, ok := names[config.Name]; ok {
+			errs = append(errs, fmt.Errorf("%s: simulation name %q is already used by %s", path, config.Name, other))
+			continue
+		}
+		names[config.Name] = path
+
+		files = append(files, &SuiteFile{Path: path, Config: config})
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if found == 0 {
+		return nil, fmt.Errorf("no simulation files found in %q", dir)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no simulations in %q match the tag filters", dir)
+	}
+
+	return files, nil
+}
+
+// isSimulationFile reports whether data is a simulation rather than another
+// JSON file kept next to simulations. JSON that can not be parsed counts as a
+// simulation, so the mistake is reported rather than skipped.
+func isSimulationFile(data []byte) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return !json.Valid(data)
+	}
+
+	_, target := fields["target"]
+	_, targets := fields["targets"]
+	return target || targets
+}
+
+// suiteTask runs a single simulation of a suite on the thread pool.
+type suiteTask struct {
+	name string
+	run  func()
+}
+
+func (t *suiteTask) GetName() string {
+	return t.name
+}
+
+func (t *suiteTask) Run() {
+	t.run()
+}
+
+// ExecuteSuite runs every simulation of a suite and writes each summary, in
+// order, once they have all finished. A dry run writes the plan of every
+// simulation instead. The error returned has the exit code of the most
+// serious failure, a configuration error before a runtime error before a
+// breached threshold.
+func ExecuteSuite(opts SuiteOptions, logger *slog.Logger) error {
+	if logger == nil {
+		return fmt.Errorf("logger cannot be nil")
+	}
+
+	if opts.Parallel <= 0 {
+		return fmt.Errorf("parallel must be greater than 0, got %d", opts.Parallel)
+	}
+
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	if len(opts.Simulations) > 0 && opts.Simulations[0].DryRun {
+		for _, simOpts := range opts.Simulations {
+			if err := ExecuteSimulation(simOpts, logger.With("simulation", simOpts.Config.Name)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	logger.Info("Starting suite", "simulations", len(opts.Simulations), "parallel", opts.Parallel)
+
+	results := make([]*simulation.SimulationResult, len(opts.Simulations))
+	outcomes := make([]error, len(opts.Simulations))
+
+	tp := threadpool.NewThreadPool(1, min(opts.Parallel, threadpool.MAX_WORKERS), 5*time.Second)
+	tp.Run()
+	defer tp.Stop()
+
+	for i, simOpts := range opts.Simulations {
+		simLogger := logger.With("simulation", simOpts.Config.Name)
+		tp.Add(&suiteTask{name: simOpts.Config.Name, run: func() {
+			result, err := RunSimulation(simOpts, simLogger)
+			if err != nil {
+				outcomes[i] = err
+				return
+			}
+
+			results[i] = result
+			outcomes[i] = SimulationOutcome(result, simOpts.Timeout, simLogger)
+		}})
+	}
+
+	tp.Wait()
+
+	var finished []*simulation.SimulationResult
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+
+		if err := WriteSummary(out, result); err != nil {
+			return err
+		}
+		fmt.Fprintln(out)
+		finished = append(finished, result)
+	}
+
+	if err := WriteReports(opts.Reports, finished); err != nil {
+		return err
+	}
+
+	if err := WriteSuiteSummary(out, opts.Simulations, outcomes); err != nil {
+		return err
+	}
+
+	return suiteError(opts.Simulations, outcomes)
+}
+
+// WriteSuiteSummary writes whether each simulation of a suite passed.
+func WriteSuiteSummary(out io.Writer, simulations []SimulationOptions, outcomes []error) error {
+	failed := 0
+	for _, err := range outcomes {
+		if err != nil {
+			failed++
+		}
+	}
+
+	if _, err := fmt.Fprintf(out, "Suite: %d simulation(s), %d passed, %d failed\n\n", len(simulations), len(simulations)-failed, failed); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SIMULATION\tRESULT")
+	for i, simOpts := range simulations {
+		verdict := "passed"
+		if outcomes[i] != nil {
+			verdict = outcomes[i].Error()
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\n", simOpts.Config.Name, verdict)
+	}
+
+	return tw.Flush()
+}
+
+func suiteError(simulations []SimulationOptions, outcomes []error) error {
+	code := ExitOK
+	var failures []string
+	for i, err := range outcomes {
+		if err == nil {
+			continue
+		}
+
+		failures = append(failures, fmt.Sprintf("%s: %s", simulations[i].Config.Name, err.Error()))
+		code = moreSerious(code, ExitCode(err))
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	return &ExitError{
+		Code: code,
+		Err:  fmt.Errorf("%d of %d simulation(s) failed: %s", len(failures), len(simulations), strings.Join(failures, "; ")),
+	}
+}
+
+// moreSerious returns whichever exit code describes the more serious failure.
+func moreSerious(a int, b int) int {
+	severity := map[int]int{ExitOK: 0, ExitThresholdBreach: 1, ExitRuntimeError: 2, ExitConfigError: 3}
+	if severity[b] > severity[a] {
+		return b
+	}
+
+	return a
+}
@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/report"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+)
+
+func TestMatchesTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		include  []string
+		exclude  []string
+		expected bool
+	}{
+		{name: "NoFilters", tags: []string{"smoke"}, expected: true},
+		{name: "NoTagsNoFilters", tags: nil, expected: true},
+		{name: "Included", tags: []string{"smoke", "orders"}, include: []string{"orders"}, expected: true},
+		{name: "AnyIncluded", tags: []string{"orders"}, include: []string{"smoke", "orders"}, expected: true},
+		{name: "NotIncluded", tags: []string{"smoke"}, include: []string{"orders"}, expected: false},
+		{name: "UntaggedNotIncluded", tags: nil, include: []string{"smoke"}, expected: false},
+		{name: "Skipped", tags: []string{"smoke", "slow"}, exclude: []string{"slow"}, expected: false},
+		{name: "SkipWins", tags: []string{"smoke", "slow"}, include: []string{"smoke"}, exclude: []string{"slow"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchesTags(tt.tags, tt.include, tt.exclude); got != tt.expected {
+				t.Errorf("MatchesTags(%v, %v, %v) = %v, want %v", tt.tags, tt.include, tt.exclude, got, tt.expected)
+			}
+		})
+	}
+}
+
+func writeSuiteFile(t *testing.T, dir string, name string, content string) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func suiteConfig(name string, url string, tags ...string) string {
+	return fmt.Sprintf(`{"name": %q, "tags": [%s], "attempts": 1, "target": {"count": 1, "client": {"method": "GET", "url": %q}}}`,
+		name, strings.Join(quoteAll(tags), ", "), url)
+}
+
+func quoteAll(values []string) []string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, fmt.Sprintf("%q", v))
+	}
+
+	return quoted
+}
+
+func TestLoadSuite(t *testing.T) {
+	dir := t.TempDir()
+	writeSuiteFile(t, dir, "orders.json", suiteConfig("Orders", "http://localhost/orders", "smoke", "orders"))
+	writeSuiteFile(t, dir, "payments/payments.json", suiteConfig("Payments", "http://localhost/payments", "smoke", "slow"))
+	writeSuiteFile(t, dir, "payments/users.csv", "email\na@example.com\n")
+	writeSuiteFile(t, dir, "payments/payload.json", `{"amount": 10, "currency": "GBP"}`)
+	writeSuiteFile(t, dir, "payments/batch.json", `[{"amount": 10}]`)
+	writeSuiteFile(t, dir, ".cache/old.json", `{invalid json`)
+	writeSuiteFile(t, dir, "search.json", suiteConfig("Search", "http://localhost/search"))
+
+	tests := []struct {
+		name        string
+		include     []string
+		exclude     []string
+		expected    []string
+		expectError bool
+	}{
+		{name: "All", expected: []string{"Orders", "Payments", "Search"}},
+		{name: "Tag", include: []string{"smoke"}, expected: []string{"Orders", "Payments"}},
+		{name: "SkipTag", include: []string{"smoke"}, exclude: []string{"slow"}, expected: []string{"Orders"}},
+		{name: "NoneMatch", include: []string{"nightly"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := LoadSuite(dir, tt.include, tt.exclude)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("LoadSuite() expected error but got %d file(s)", len(files))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadSuite() unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, file := range files {
+				names = append(names, file.Config.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("LoadSuite() = %v, want %v", names, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadSuiteErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{name: "Empty", files: map[string]string{"notes.txt": "not a simulation"}, want: "no simulation files found"},
+		{name: "OnlyFixtures", files: map[string]string{"payload.json": `{"name": "fixture"}`}, want: "no simulation files found"},
+		{name: "Invalid", files: map[string]string{"broken.json": `{invalid json`}, want: "broken.json: failed to parse JSON configuration"},
+		{name: "UnknownField", files: map[string]string{"typo.json": `{"name": "Typo", "attempts": 1, "target": {"count": 1, "clinet": {}}}`}, want: `unknown field "clinet"`},
+		{name: "NothingToRun", files: map[string]string{"empty.json": `{"name": "Empty", "attempts": 1, "target": {"count": 1}}`}, want: "target must have a client or a monitor"},
+		{name: "DuplicateNames", files: map[string]string{
+			"a.json": suiteConfig("Orders", "http://localhost/a"),
+			"b.json": suiteConfig("Orders", "http://localhost/b"),
+		}, want: `simulation name "Orders" is already used by`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeSuiteFile(t, dir, name, content)
+			}
+
+			_, err := LoadSuite(dir, nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadSuite() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSuiteResolvesPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeSuiteFile(t, dir, "orders/users.csv", "email\na@example.com\n")
+	writeSuiteFile(t, dir, "orders/order.json", `{"item": "book"}`)
+	writeSuiteFile(t, dir, "orders/orders.json", `{
+		"name": "Orders",
+		"attempts": 1,
+		"data": {"users": {"file": "users.csv"}},
+		"target": {"count": 1, "client": {"method": "POST", "url": "http://localhost/orders", "body": "@order.json"}}
+	}`)
+
+	t.Chdir(t.TempDir())
+
+	files, err := LoadSuite(dir, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadSuite() unexpected error: %v", err)
+	}
+
+	config := files[0].Config
+	if want := filepath.Join(dir, "orders", "users.csv"); config.Data["users"].File != want {
+		t.Errorf("data file = %q, want %q", config.Data["users"].File, want)
+	}
+
+	if want := fmt.Sprintf("%q", "@"+filepath.Join(dir, "orders", "order.json")); string(config.Target.Client.Body) != want {
+		t.Errorf("body = %s, want %s", config.Target.Client.Body, want)
+	}
+}
+
+func TestExecuteSuite(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/broken" {
+			res.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	noErrors := 0.0
+	suiteSimulation := func(name string, path string) SimulationOptions {
+		return SimulationOptions{
+			Config: &simulation.SimulationConfig{
+				Name:     name,
+				Attempts: 1,
+				Target: simulation.SimulationTargetConfig{
+					Count:  2,
+					Client: &api.ClientConfig{Method: "GET", Url: server.URL + path},
+				},
+				Thresholds: &simulation.Thresholds{MaxErrorRate: &noErrors},
+			},
+			Workers: 2,
+			Timeout: 5 * time.Second,
+		}
+	}
+
+	resultsPath := filepath.Join(t.TempDir(), "results.json")
+	var out bytes.Buffer
+	err := ExecuteSuite(SuiteOptions{
+		Simulations: []SimulationOptions{suiteSimulation("Healthy", "/ok"), suiteSimulation("Broken", "/broken"), suiteSimulation("Also Healthy", "/ok")},
+		Parallel:    2,
+		Out:         &out,
+		Reports:     []ReportSpec{{Format: ReportFormatJSON, Path: resultsPath}},
+	}, slog.Default())
+
+	if code := ExitCode(err); code != ExitThresholdBreach {
+		t.Errorf("ExecuteSuite() exit code = %d, want %d, error: %v", code, ExitThresholdBreach, err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 simulation(s) failed: Broken: thresholds breached") {
+		t.Errorf("ExecuteSuite() error = %v", err)
+	}
+
+	for _, want := range []string{"Suite: 3 simulation(s), 2 passed, 1 failed", `Simulation "Healthy" finished`, `Simulation "Also Healthy" finished`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out.String())
+		}
+	}
+
+	file, err := os.Open(resultsPath)
+	if err != nil {
+		t.Fatalf("combined report was not written: %v", err)
+	}
+	defer file.Close()
+
+	results, err := report.ReadJSON(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || results[0].Name != "Healthy" || results[1].Name != "Broken" || results[2].Name != "Also Healthy" {
+		t.Errorf("combined report has %d simulation(s), want all 3 in suite order", len(results))
+	}
+}
+
+func TestSuiteError(t *testing.T) {
+	simulations := []SimulationOptions{
+		{Config: &simulation.SimulationConfig{Name: "a"}},
+		{Config: &simulation.SimulationConfig{Name: "b"}},
+	}
+
+	tests := []struct {
+		name     string
+		outcomes []error
+		expected int
+	}{
+		{name: "Passed", outcomes: []error{nil, nil}, expected: ExitOK},
+		{name: "Threshold", outcomes: []error{NewThresholdError(fmt.Errorf("breached")), nil}, expected: ExitThresholdBreach},
+		{name: "RuntimeBeforeThreshold", outcomes: []error{NewThresholdError(fmt.Errorf("breached")), fmt.Errorf("timed out")}, expected: ExitRuntimeError},
+		{name: "ConfigBeforeRuntime", outcomes: []error{fmt.Errorf("timed out"), NewConfigError(fmt.Errorf("bad data"))}, expected: ExitConfigError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ExitCode(suiteError(simulations, tt.outcomes)); code != tt.expected {
+				t.Errorf("suiteError() exit code = %d, want %d", code, tt.expected)
+			}
+		})
+	}
+}
@@ -55,12 +55,12 @@ func validateConfig(cmd *cobra.Command, args []string) error {
 
 	config, err := ParseConfigReader(file, true) // strict mode
 	if err != nil {
-		return err
+		return NewConfigError(err)
 	}
 
 	validationErrors := ValidateConfigData(config)
 	if len(validationErrors) > 0 {
-		return FormatValidationErrors(validationErrors)
+		return NewConfigError(FormatValidationErrors(validationErrors))
 	}
 
 	fmt.Printf("✓ Configuration file %q is valid\n", configPath)
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/templating"
+)
+
+const (
+	AuthTypeBearer = "bearer"
+	AuthTypeBasic  = "basic"
+	AuthTypeAPIKey = "apikey"
+
+	DefaultAPIKeyHeader = "X-API-Key"
+)
+
+type AuthConfig struct {
+	Type     string `json:"type"`
+	Token    string `json:"token,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Header   string `json:"header,omitempty"`
+	Key      string `json:"key,omitempty"`
+}
+
+// IsTemplate reports whether any credential in the config is a template that
+// has to be rendered for each request.
+func (c *AuthConfig) IsTemplate() bool {
+	if c == nil {
+		return false
+	}
+
+	for _, v := range []string{c.Token, c.Username, c.Password, c.Header, c.Key} {
+		if templating.IsTemplate(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Render returns a copy of the config with every credential rendered against
+// vars.
+func (c *AuthConfig) Render(vars map[string]any) (*AuthConfig, error) {
+	rendered := *c
+
+	for _, field := range []*string{&rendered.Token, &rendered.Username, &rendered.Password, &rendered.Header, &rendered.Key} {
+		v, err := templating.Render(*field, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		*field = v
+	}
+
+	return &rendered, nil
+}
+
+// Authenticator adds credentials to an outgoing request.
+type Authenticator interface {
+	Apply(req *http.Request) error
+}
+
+type BearerAuth struct {
+	Token string
+}
+
+func (a BearerAuth) Apply(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+func (a BasicAuth) Apply(req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+type APIKeyAuth struct {
+	Header string
+	Key    string
+}
+
+func (a APIKeyAuth) Apply(req *http.Request) error {
+	req.Header.Set(a.Header, a.Key)
+	return nil
+}
+
+// NewAuthenticator builds the Authenticator described by config. A nil config
+// returns a nil Authenticator.
+func NewAuthenticator(config *AuthConfig) (Authenticator, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	switch strings.ToLower(config.Type) {
+	case AuthTypeBearer:
+		if config.Token == "" {
+			return nil, fmt.Errorf("bearer auth requires a token")
+		}
+
+		return BearerAuth{Token: config.Token}, nil
+	case AuthTypeBasic:
+		if config.Username == "" {
+			return nil, fmt.Errorf("basic auth requires a username")
+		}
+
+		return BasicAuth{Username: config.Username, Password: config.Password}, nil
+	case AuthTypeAPIKey:
+		if config.Key == "" {
+			return nil, fmt.Errorf("apiKey auth requires a key")
+		}
+
+		header := config.Header
+		if header == "" {
+			header = DefaultAPIKeyHeader
+		}
+
+		return APIKeyAuth{Header: header, Key: config.Key}, nil
+	case "":
+		return nil, fmt.Errorf("auth type is required")
+	default:
+		return nil, fmt.Errorf("unsupported auth type %q, expected one of bearer, basic or apiKey", config.Type)
+	}
+}
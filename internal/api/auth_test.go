@@ -0,0 +1,122 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+)
+
+func TestNewAuthenticator(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *api.AuthConfig
+		header      string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Bearer",
+			config:   &api.AuthConfig{Type: "bearer", Token: "abc123"},
+			header:   "Authorization",
+			expected: "Bearer abc123",
+		},
+		{
+			name:     "Basic",
+			config:   &api.AuthConfig{Type: "basic", Username: "user", Password: "pass"},
+			header:   "Authorization",
+			expected: "Basic dXNlcjpwYXNz",
+		},
+		{
+			name:     "APIKeyDefaultHeader",
+			config:   &api.AuthConfig{Type: "apiKey", Key: "secret"},
+			header:   api.DefaultAPIKeyHeader,
+			expected: "secret",
+		},
+		{
+			name:     "APIKeyCustomHeader",
+			config:   &api.AuthConfig{Type: "apiKey", Header: "X-Service-Token", Key: "secret"},
+			header:   "X-Service-Token",
+			expected: "secret",
+		},
+		{
+			name:        "BearerMissingToken",
+			config:      &api.AuthConfig{Type: "bearer"},
+			expectError: true,
+		},
+		{
+			name:        "BasicMissingUsername",
+			config:      &api.AuthConfig{Type: "basic", Password: "pass"},
+			expectError: true,
+		},
+		{
+			name:        "APIKeyMissingKey",
+			config:      &api.AuthConfig{Type: "apiKey"},
+			expectError: true,
+		},
+		{
+			name:        "MissingType",
+			config:      &api.AuthConfig{Token: "abc123"},
+			expectError: true,
+		},
+		{
+			name:        "UnknownType",
+			config:      &api.AuthConfig{Type: "digest"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := api.NewAuthenticator(tt.config)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("NewAuthenticator() expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewAuthenticator() unexpected error: %v", err)
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, "http://localhost/test", nil)
+			if err := auth.Apply(req); err != nil {
+				t.Fatalf("Apply() unexpected error: %v", err)
+			}
+
+			if got := req.Header.Get(tt.header); got != tt.expected {
+				t.Errorf("Apply() header %s = %q, want %q", tt.header, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewAuthenticatorNilConfig(t *testing.T) {
+	auth, err := api.NewAuthenticator(nil)
+	if auth != nil || err != nil {
+		t.Errorf("NewAuthenticator(nil) = %v, %v, want nil, nil", auth, err)
+	}
+}
+
+func TestAuthConfigRender(t *testing.T) {
+	config := &api.AuthConfig{Type: "bearer", Token: "{{.token}}"}
+	if !config.IsTemplate() {
+		t.Fatal("IsTemplate() = false, want true")
+	}
+
+	rendered, err := config.Render(map[string]any{"token": "abc123"})
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	if rendered.Token != "abc123" {
+		t.Errorf("rendered token = %q, want %q", rendered.Token, "abc123")
+	}
+	if config.Token != "{{.token}}" {
+		t.Errorf("Render() modified the original config, token = %q", config.Token)
+	}
+
+	if _, err := config.Render(nil); err == nil {
+		t.Error("Render() with a missing variable returned no error")
+	}
+}
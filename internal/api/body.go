@@ -0,0 +1,471 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/templating"
+)
+
+const (
+	fileReferencePrefix = "@"
+
+	mediaTypeJSON      = "application/json"
+	mediaTypeForm      = "application/x-www-form-urlencoded"
+	mediaTypeMultipart = "multipart/form-data"
+)
+
+// Body is a request payload taken from a client config. It keeps the raw
+// config value and is rebuilt on every send so that each attempt gets a fresh
+// reader.
+//
+// The raw value is interpreted as follows:
+//   - a JSON string starting with "@" is a reference to a file whose contents
+//     are sent, "@@" escapes a literal leading "@", and the path is used as
+//     written rather than rendered as a template
+//   - any other JSON string is sent as is
+//   - an object is encoded according to the content type, as url encoded form
+//     values, multipart form fields where "@" values upload files, or JSON
+//   - any other JSON value is sent as JSON
+type Body struct {
+	raw json.RawMessage
+}
+
+func NewBody(raw json.RawMessage) *Body {
+	if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil
+	}
+
+	return &Body{
+		raw: raw,
+	}
+}
+
+// Build creates a fresh reader for the body along with the content type that
+// should be sent with it. Strings in the body, including the contents of
+// referenced files, are rendered as templates against vars. Only the config
+// value itself can reference a file, never what it renders to, so a variable
+// can not make the body read a local file. A nil Body builds a nil reader.
+func (b *Body) Build(contentType string, vars map[string]any) (io.Reader, string, error) {
+	if b == nil {
+		return nil, contentType, nil
+	}
+
+	value, err := b.decode()
+	if err != nil {
+		return nil, contentType, err
+	}
+
+	if s, ok := value.(string); ok {
+		data, err := readStringBody(s, vars)
+		if err != nil {
+			return nil, contentType, err
+		}
+
+		return bytes.NewReader(data), contentType, nil
+	}
+
+	// File fields and escapes are found before rendering, for the same
+	// reason as a string body.
+	var files map[string]string
+	if fields, ok := value.(map[string]any); ok {
+		switch mediaType(contentType) {
+		case mediaTypeMultipart:
+			files = fileFields(fields)
+			unescapeFields(fields)
+		case mediaTypeForm:
+			unescapeFields(fields)
+		}
+	}
+
+	raw := []byte(b.raw)
+	if templating.IsTemplate(string(b.raw)) {
+		value, err = templating.RenderValue(value, vars)
+		if err != nil {
+			return nil, contentType, fmt.Errorf("failed to render body: %w", err)
+		}
+
+		raw, err = json.Marshal(value)
+		if err != nil {
+			return nil, contentType, fmt.Errorf("failed to encode rendered body: %w", err)
+		}
+	}
+
+	fields, isObject := value.(map[string]any)
+	switch mediaType(contentType) {
+	case mediaTypeForm:
+		if !isObject {
+			return nil, contentType, fmt.Errorf("form body must be an object or a string")
+		}
+
+		values, err := formValues(fields)
+		if err != nil {
+			return nil, contentType, err
+		}
+
+		return strings.NewReader(values.Encode()), contentType, nil
+	case mediaTypeMultipart:
+		if !isObject {
+			return nil, contentType, fmt.Errorf("multipart body must be an object or a string")
+		}
+
+		return multipartBody(fields, files)
+	}
+
+	if contentType == "" {
+		contentType = mediaTypeJSON
+	}
+
+	return bytes.NewReader(raw), contentType, nil
+}
+
+// Validate checks the body can be built for contentType without reading any
+// referenced files.
+func (b *Body) Validate(contentType string) error {
+	if b == nil {
+		return nil
+	}
+
+	value, err := b.decode()
+	if err != nil {
+		return err
+	}
+
+	if err := validateTemplates(value); err != nil {
+		return err
+	}
+
+	if s, ok := value.(string); ok {
+		return checkFileReference(s)
+	}
+
+	fields, isObject := value.(map[string]any)
+	switch mediaType(contentType) {
+	case mediaTypeForm:
+		if !isObject {
+			return fmt.Errorf("form body must be an object or a string")
+		}
+
+		_, err := formValues(fields)
+		return err
+	case mediaTypeMultipart:
+		if !isObject {
+			return fmt.Errorf("multipart body must be an object or a string")
+		}
+
+		for _, k := range sortedKeys(fields) {
+			if s, ok := fields[k].(string); ok {
+				if err := checkFileReference(s); err != nil {
+					return err
+				}
+			}
+		}
+
+		_, err := formValues(fields)
+		return err
+	}
+
+	return nil
+}
+
+func validateTemplates(value any) error {
+	switch v := value.(type) {
+	case string:
+		return templating.Validate(v)
+	case map[string]any:
+		for _, k := range sortedKeys(v) {
+			if err := validateTemplates(v[k]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := validateTemplates(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b *Body) decode() (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(b.raw))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode body: %w", err)
+	}
+
+	return value, nil
+}
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	return mt
+}
+
+func fileReference(s string) (string, bool) {
+	if !strings.HasPrefix(s, fileReferencePrefix) || strings.HasPrefix(s, fileReferencePrefix+fileReferencePrefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(s, fileReferencePrefix), true
+}
+
+func unescapeFileReference(s string) string {
+	if strings.HasPrefix(s, fileReferencePrefix+fileReferencePrefix) {
+		return strings.TrimPrefix(s, fileReferencePrefix)
+	}
+
+	return s
+}
+
+// fileFields returns the path of every field of a multipart body that
+// uploads a file.
+func fileFields(fields map[string]any) map[string]string {
+	files := make(map[string]string)
+	for k, field := range fields {
+		if s, ok := field.(string); ok {
+			if path, isFile := fileReference(s); isFile {
+				files[k] = path
+			}
+		}
+	}
+
+	return files
+}
+
+// unescapeFields replaces every field value, or list item, escaping a
+// leading "@" with the value it stands for.
+func unescapeFields(fields map[string]any) {
+	for k, field := range fields {
+		switch v := field.(type) {
+		case string:
+			fields[k] = unescapeFileReference(v)
+		case []any:
+			for i, item := range v {
+				if s, ok := item.(string); ok {
+					v[i] = unescapeFileReference(s)
+				}
+			}
+		}
+	}
+}
+
+func checkFileReference(s string) error {
+	path, ok := fileReference(s)
+	if !ok {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot access body file %q: %w", path, err)
+	}
+
+	if info.IsDir() {
+		return fmt.Errorf("body file %q is a directory", path)
+	}
+
+	return nil
+}
+
+// readStringBody renders a string body, or reads and renders the file it
+// references. The reference is taken from the config string as is, so a
+// rendered value starting with "@" is sent rather than read as a path.
+func readStringBody(s string, vars map[string]any) ([]byte, error) {
+	path, ok := fileReference(s)
+	if !ok {
+		rendered, err := templating.Render(unescapeFileReference(s), vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render body: %w", err)
+		}
+
+		return []byte(rendered), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body file %q: %w", path, err)
+	}
+
+	rendered, err := templating.Render(string(data), vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render body file %q: %w", path, err)
+	}
+
+	return []byte(rendered), nil
+}
+
+// resolveBodyPaths returns raw with the relative paths of referenced files
+// joined to dir, the body files sent as is or uploaded as multipart files.
+// A body that can not be decoded is left for Validate to report.
+func resolveBodyPaths(raw json.RawMessage, contentType string, dir string) json.RawMessage {
+	b := NewBody(raw)
+	if b == nil {
+		return raw
+	}
+
+	value, err := b.decode()
+	if err != nil {
+		return raw
+	}
+
+	resolve := func(s string) (string, bool) {
+		path, ok := fileReference(s)
+		if !ok || filepath.IsAbs(path) {
+			return s, false
+		}
+
+		return fileReferencePrefix + filepath.Join(dir, path), true
+	}
+
+	changed := false
+	switch v := value.(type) {
+	case string:
+		value, changed = resolve(v)
+	case map[string]any:
+		if mediaType(contentType) != mediaTypeMultipart {
+			return raw
+		}
+
+		for k, field := range v {
+			if s, ok := field.(string); ok {
+				if resolved, ok := resolve(s); ok {
+					v[k] = resolved
+					changed = true
+				}
+			}
+		}
+	}
+
+	if !changed {
+		return raw
+	}
+
+	resolved, err := json.Marshal(value)
+	if err != nil {
+		return raw
+	}
+
+	return resolved
+}
+
+func sortedKeys(fields map[string]any) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func formValue(key string, value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("form field %q must be a string, number, boolean or a list of them", key)
+	}
+}
+
+func formValues(fields map[string]any) (url.Values, error) {
+	values := url.Values{}
+	for _, k := range sortedKeys(fields) {
+		items, isList := fields[k].([]any)
+		if !isList {
+			items = []any{fields[k]}
+		}
+
+		for _, item := range items {
+			v, err := formValue(k, item)
+			if err != nil {
+				return nil, err
+			}
+
+			values.Add(k, v)
+		}
+	}
+
+	return values, nil
+}
+
+// multipartBody writes fields as a multipart form, uploading the file of
+// every field in files.
+func multipartBody(fields map[string]any, files map[string]string) (io.Reader, string, error) {
+	buf := &bytes.Buffer{}
+	writer := multipart.NewWriter(buf)
+
+	for _, k := range sortedKeys(fields) {
+		if path, isFile := files[k]; isFile {
+			if err := writeMultipartFile(writer, k, path); err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+
+		items, isList := fields[k].([]any)
+		if !isList {
+			items = []any{fields[k]}
+		}
+
+		for _, item := range items {
+			v, err := formValue(k, item)
+			if err != nil {
+				return nil, "", err
+			}
+
+			if err := writer.WriteField(k, v); err != nil {
+				return nil, "", fmt.Errorf("failed to write multipart field %q: %w", k, err)
+			}
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to close multipart body: %w", err)
+	}
+
+	return buf, writer.FormDataContentType(), nil
+}
+
+func writeMultipartFile(writer *multipart.Writer, field string, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open multipart file %q: %w", path, err)
+	}
+	defer file.Close()
+
+	part, err := writer.CreateFormFile(field, filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("failed to create multipart file part %q: %w", field, err)
+	}
+
+	if _, err := io.Copy(part, file); err != nil {
+		return fmt.Errorf("failed to copy multipart file %q: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package api_test
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+)
+
+func TestBodyBuild(t *testing.T) {
+	dir := t.TempDir()
+	payload := filepath.Join(dir, "payload.json")
+	if err := os.WriteFile(payload, []byte(`{"from":"file"}`), 0o644); err != nil {
+		t.Fatalf("Failed to write payload file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		raw         string
+		contentType string
+		expected    string
+		expectedCT  string
+	}{
+		{
+			name:        "InlineJSON",
+			raw:         `{"some": "data", "count": 12345678901234567890}`,
+			contentType: "application/json",
+			expected:    `{"some": "data", "count": 12345678901234567890}`,
+			expectedCT:  "application/json",
+		},
+		{
+			name:        "InlineJSONDefaultsContentType",
+			raw:         `[1, 2, 3]`,
+			contentType: "",
+			expected:    `[1, 2, 3]`,
+			expectedCT:  "application/json",
+		},
+		{
+			name:        "RawString",
+			raw:         `"plain text body"`,
+			contentType: "text/plain",
+			expected:    "plain text body",
+			expectedCT:  "text/plain",
+		},
+		{
+			name:        "FileReference",
+			raw:         `"@` + payload + `"`,
+			contentType: "application/json",
+			expected:    `{"from":"file"}`,
+			expectedCT:  "application/json",
+		},
+		{
+			name:        "EscapedFileReference",
+			raw:         `"@@handle"`,
+			contentType: "text/plain",
+			expected:    "@handle",
+			expectedCT:  "text/plain",
+		},
+		{
+			name:        "FormUrlEncoded",
+			raw:         `{"name": "test user", "tags": ["a", "b"], "count": 2, "admin": false}`,
+			contentType: "application/x-www-form-urlencoded",
+			expected:    "admin=false&count=2&name=test+user&tags=a&tags=b",
+			expectedCT:  "application/x-www-form-urlencoded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := api.NewBody(json.RawMessage(tt.raw))
+
+			for attempt := 0; attempt < 2; attempt++ {
+				reader, contentType, err := body.Build(tt.contentType, nil)
+				if err != nil {
+					t.Fatalf("Build() unexpected error: %v", err)
+				}
+
+				data, _ := io.ReadAll(reader)
+				if string(data) != tt.expected {
+					t.Errorf("Build() attempt %d body = %q, want %q", attempt, string(data), tt.expected)
+				}
+				if contentType != tt.expectedCT {
+					t.Errorf("Build() attempt %d content type = %q, want %q", attempt, contentType, tt.expectedCT)
+				}
+			}
+		})
+	}
+}
+
+func TestBodyBuildMultipart(t *testing.T) {
+	dir := t.TempDir()
+	upload := filepath.Join(dir, "upload.txt")
+	if err := os.WriteFile(upload, []byte("file contents"), 0o644); err != nil {
+		t.Fatalf("Failed to write upload file: %v", err)
+	}
+
+	body := api.NewBody(json.RawMessage(`{"name": "test", "file": "@` + upload + `"}`))
+	reader, contentType, err := body.Build("multipart/form-data", nil)
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		t.Fatalf("Build() content type = %q, want multipart/form-data with boundary", contentType)
+	}
+
+	form, err := multipart.NewReader(reader, params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("Failed to read multipart form: %v", err)
+	}
+
+	if got := form.Value["name"]; len(got) != 1 || got[0] != "test" {
+		t.Errorf("multipart field name = %v, want [test]", got)
+	}
+
+	files := form.File["file"]
+	if len(files) != 1 || files[0].Filename != "upload.txt" {
+		t.Fatalf("multipart file = %v, want upload.txt", files)
+	}
+
+	file, _ := files[0].Open()
+	data, _ := io.ReadAll(file)
+	file.Close()
+	if string(data) != "file contents" {
+		t.Errorf("multipart file contents = %q, want %q", string(data), "file contents")
+	}
+}
+
+func TestBodyValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		contentType string
+		expectError bool
+	}{
+		{name: "NullBody", raw: `null`, contentType: "application/json", expectError: false},
+		{name: "JSONObject", raw: `{"a": 1}`, contentType: "application/json", expectError: false},
+		{name: "MissingFile", raw: `"@does-not-exist.json"`, contentType: "application/json", expectError: true},
+		{name: "FormArray", raw: `[1, 2]`, contentType: "application/x-www-form-urlencoded", expectError: true},
+		{name: "FormNestedObject", raw: `{"a": {"b": 1}}`, contentType: "application/x-www-form-urlencoded", expectError: true},
+		{name: "MultipartMissingFile", raw: `{"file": "@missing.bin"}`, contentType: "multipart/form-data", expectError: true},
+		{name: "InvalidJSON", raw: `{invalid`, contentType: "application/json", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := api.NewBody(json.RawMessage(tt.raw)).Validate(tt.contentType)
+			if tt.expectError && err == nil {
+				t.Errorf("Validate() expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Validate() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewBodyEmpty(t *testing.T) {
+	for _, raw := range []string{"", "null", "  "} {
+		if body := api.NewBody(json.RawMessage(raw)); body != nil {
+			t.Errorf("NewBody(%q) = %v, want nil", raw, body)
+		}
+	}
+
+	reader, _, err := (*api.Body)(nil).Build("application/json", nil)
+	if err != nil || reader != nil {
+		t.Errorf("nil Body Build() = %v, %v, want nil reader", reader, err)
+	}
+}
+
+func TestBodyReachesServer(t *testing.T) {
+	received := make(chan string, 2)
+	server := newEchoServer(t, received)
+
+	client := api.NewClient(api.NewClientParams("POST", server.URL, "application/json", api.NewBody(json.RawMessage(`{"some":"data"}`))))
+	for attempt := 0; attempt < 2; attempt++ {
+		resp, err := client.Do(t.Context(), nil)
+		if err != nil {
+			t.Fatalf("Do() unexpected error: %v", err)
+		}
+		resp.Body.Close()
+
+		if got := <-received; !strings.Contains(got, `"some":"data"`) {
+			t.Errorf("attempt %d server received %q, want the configured body", attempt, got)
+		}
+	}
+}
+
+func TestClientConfigResolvePaths(t *testing.T) {
+	dir := filepath.Join("testdata", "orders")
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{name: "FileBody", body: `"@order.json"`, expected: `"@` + filepath.Join(dir, "order.json") + `"`},
+		{name: "AbsoluteFileBody", body: `"@/tmp/order.json"`, expected: `"@/tmp/order.json"`},
+		{name: "EscapedBody", body: `"@@handle"`, expected: `"@@handle"`},
+		{name: "MultipartFile", contentType: "multipart/form-data", body: `{"file":"@invoice.pdf","note":"hi"}`, expected: `{"file":"@` + filepath.Join(dir, "invoice.pdf") + `","note":"hi"}`},
+		{name: "JSONObject", contentType: "application/json", body: `{"handle": "@someone"}`, expected: `{"handle": "@someone"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &api.ClientConfig{
+				ContentType: tt.contentType,
+				Body:        json.RawMessage(tt.body),
+				Transport:   &api.TransportConfig{CACert: "ca.pem", ClientCert: "/etc/client.pem"},
+			}
+			config.ResolvePaths(dir)
+
+			if string(config.Body) != tt.expected {
+				t.Errorf("body = %s, want %s", config.Body, tt.expected)
+			}
+
+			if config.Transport.CACert != filepath.Join(dir, "ca.pem") || config.Transport.ClientCert != "/etc/client.pem" {
+				t.Errorf("transport = %+v, want only the relative caCert resolved", config.Transport)
+			}
+		})
+	}
+}
+
+func TestBodyBuildRenderedFileReference(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(secret, []byte("top-secret"), 0o644); err != nil {
+		t.Fatalf("Failed to write secret file: %v", err)
+	}
+	vars := map[string]any{"payload": "@" + secret}
+
+	reader, _, err := api.NewBody(json.RawMessage(`"{{.payload}}"`)).Build("text/plain", vars)
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	if data, _ := io.ReadAll(reader); string(data) != "@"+secret {
+		t.Errorf("Build() body = %q, want the rendered value sent as is", string(data))
+	}
+
+	reader, contentType, err := api.NewBody(json.RawMessage(`{"file": "{{.payload}}"}`)).Build("multipart/form-data", vars)
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+
+	_, params, _ := mime.ParseMediaType(contentType)
+	form, err := multipart.NewReader(reader, params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("Failed to read multipart form: %v", err)
+	}
+	if len(form.File["file"]) != 0 || len(form.Value["file"]) != 1 || form.Value["file"][0] != "@"+secret {
+		t.Errorf("multipart field file = %v, files %v, want the rendered value as a plain field", form.Value["file"], form.File["file"])
+	}
+}
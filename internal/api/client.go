@@ -1,34 +1,165 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/Easy-Infra-Ltd/easy-test/internal/assert"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/templating"
 )
 
 type ClientConfig struct {
-	Url         string `json:"url"`
-	ContentType string `json:"contentType"`
+	Method      string            `json:"method"`
+	Url         string            `json:"url"`
+	ContentType string            `json:"contentType"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Auth        *AuthConfig       `json:"auth,omitempty"`
+	Body        json.RawMessage   `json:"body,omitempty"`
+	Transport   *TransportConfig  `json:"transport,omitempty"`
+}
+
+// MethodOrDefault returns the configured method upper cased, falling back to
+// def when the config does not set one.
+func (c *ClientConfig) MethodOrDefault(def string) string {
+	if c.Method == "" {
+		return def
+	}
+
+	return strings.ToUpper(c.Method)
+}
+
+// ResolvePaths makes the relative paths of body files and certificates in the
+// config relative to dir rather than the working directory, for a config read
+// from a file in dir.
+func (c *ClientConfig) ResolvePaths(dir string) {
+	if c == nil {
+		return
+	}
+
+	c.Body = resolveBodyPaths(c.Body, c.ContentType, dir)
+	c.Transport.resolvePaths(dir)
+}
+
+// ValidMethod reports whether method is a usable HTTP method. Custom verbs are
+// allowed as long as they are valid RFC 9110 tokens.
+func ValidMethod(method string) bool {
+	return isToken(method)
+}
+
+// ValidHeaderName reports whether name can be sent as an HTTP header field name.
+func ValidHeaderName(name string) bool {
+	return isToken(name)
+}
+
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r > 0x7e || r <= 0x20 || strings.ContainsRune("\"(),/:;<=>?@[\\]{}", r) {
+			return false
+		}
+	}
+
+	return true
 }
 
 type ClientParams struct {
+	method      string
 	url         string
 	contentType string
-	body        io.Reader
+	body        *Body
+	headers     http.Header
+	auth        Authenticator
+	authConfig  *AuthConfig
+	httpClient  *http.Client
 }
 
-func NewClientParams(url string, contentType string, body io.Reader) *ClientParams {
+type ClientOption func(*ClientParams)
+
+func WithHeaders(headers map[string]string) ClientOption {
+	return func(p *ClientParams) {
+		for k, v := range headers {
+			p.headers.Set(k, v)
+		}
+	}
+}
+
+func WithAuth(auth Authenticator) ClientOption {
+	return func(p *ClientParams) {
+		p.auth = auth
+	}
+}
+
+// withAuthConfig makes the client render config and build a new Authenticator
+// for every request, so credentials can come from request variables.
+func withAuthConfig(config *AuthConfig) ClientOption {
+	return func(p *ClientParams) {
+		p.authConfig = config
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(p *ClientParams) {
+		p.httpClient = httpClient
+	}
+}
+
+func NewClientParams(method string, url string, contentType string, body *Body, options ...ClientOption) *ClientParams {
+	assert.Assert(ValidMethod(method), "Client params method must be a valid HTTP method", "method", method)
 	assert.NotNil(url, "Client params url can not be nil")
 	assert.NotNil(contentType, "Client params contentType cannot be nil")
 
-	return &ClientParams{
+	params := &ClientParams{
+		method:      strings.ToUpper(method),
 		url:         url,
 		contentType: contentType,
 		body:        body,
+		headers:     http.Header{},
+		httpClient:  http.DefaultClient,
+	}
+
+	for _, opt := range options {
+		opt(params)
 	}
+
+	return params
+}
+
+// NewClientParamsFromConfig builds ClientParams from config, using
+// defaultMethod when the config does not set a method.
+func NewClientParamsFromConfig(config *ClientConfig, defaultMethod string) (*ClientParams, error) {
+	assert.NotNil(config, "Client config can not be nil when creating ClientParams")
+
+	method := config.MethodOrDefault(defaultMethod)
+	if !ValidMethod(method) {
+		return nil, fmt.Errorf("%q is not a valid HTTP method", config.Method)
+	}
+
+	auth, err := NewAuthenticator(config.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []ClientOption{WithHeaders(config.Headers), WithAuth(auth)}
+	if config.Auth.IsTemplate() {
+		options = append(options, withAuthConfig(config.Auth))
+	}
+
+	httpClient, err := NewHTTPClient(config.Transport)
+	if err != nil {
+		return nil, err
+	}
+
+	options = append(options, WithHTTPClient(httpClient))
+
+	return NewClientParams(method, config.Url, config.ContentType, NewBody(config.Body), options...), nil
 }
 
 type Client struct {
@@ -44,12 +175,164 @@ func NewClient(config *ClientParams) *Client {
 	}
 }
 
-func (c *Client) Post() (*http.Response, error) {
-	c.logger.Info(fmt.Sprintf("Sending POST request to url %s with contentType %s", c.config.url, c.config.contentType))
-	return http.Post(c.config.url, c.config.contentType, c.config.body)
+func (c *Client) Method() string {
+	return c.config.method
+}
+
+func (c *Client) Url() string {
+	return c.config.url
 }
 
-func (c *Client) Get() (*http.Response, error) {
-	c.logger.Info(fmt.Sprintf("Sending GET request to url %s with contentType %s", c.config.url, c.config.contentType))
-	return http.Get(c.config.url)
+// NewRequest renders the client's templates against vars and builds the
+// request Do would send, without sending it.
+func (c *Client) NewRequest(ctx context.Context, vars map[string]any) (*http.Request, error) {
+	url, err := templating.Render(c.config.url, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render url for %s request: %w", c.config.method, err)
+	}
+
+	body, contentType, err := c.config.body.Build(c.config.contentType, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build body for %s request to %s: %w", c.config.method, url, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, c.config.method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s request for %s: %w", c.config.method, url, err)
+	}
+
+	if contentType != "" && body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	headers, err := templating.RenderHeaders(c.config.headers, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render headers for %s request to %s: %w", c.config.method, url, err)
+	}
+
+	for k, v := range headers {
+		if http.CanonicalHeaderKey(k) == "Host" {
+			req.Host = v[0]
+			continue
+		}
+
+		req.Header[k] = v
+	}
+
+	auth := c.config.auth
+	if c.config.authConfig != nil {
+		rendered, err := c.config.authConfig.Render(vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render auth for %s request to %s: %w", c.config.method, url, err)
+		}
+
+		auth, err = NewAuthenticator(rendered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build auth for %s request to %s: %w", c.config.method, url, err)
+		}
+	}
+
+	if auth != nil {
+		if err := auth.Apply(req); err != nil {
+			return nil, fmt.Errorf("failed to apply auth to %s request for %s: %w", c.config.method, url, err)
+		}
+	}
+
+	return req, nil
+}
+
+// Do sends the configured request. The url, header values and body are
+// rendered as templates against vars before sending.
+func (c *Client) Do(ctx context.Context, vars map[string]any) (*http.Response, error) {
+	req, err := c.NewRequest(ctx, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Send(req)
+}
+
+// Send sends a request built by NewRequest with the client's transport.
+func (c *Client) Send(req *http.Request) (*http.Response, error) {
+	return c.SendWith(nil, req)
+}
+
+// SendWith sends a request built by NewRequest as part of session, with the
+// session's cookies and connections. A nil session sends it like Send.
+func (c *Client) SendWith(session *Session, req *http.Request) (*http.Response, error) {
+	c.logger.Info(fmt.Sprintf("Sending %s request to url %s with contentType %s", req.Method, req.URL, c.config.contentType))
+
+	httpClient := c.config.httpClient
+	if session != nil {
+		httpClient = session.httpClient(httpClient)
+	}
+
+	return httpClient.Do(req)
+}
+
+// apiKeyHeader returns the header the client sends its api key in, or "" when
+// it does not use api key auth.
+func (c *Client) apiKeyHeader(vars map[string]any) string {
+	if auth, ok := c.config.auth.(APIKeyAuth); ok {
+		return auth.Header
+	}
+
+	config := c.config.authConfig
+	if config == nil || !strings.EqualFold(config.Type, AuthTypeAPIKey) {
+		return ""
+	}
+
+	header, err := templating.Render(config.Header, vars)
+	if err != nil || header == "" {
+		return DefaultAPIKeyHeader
+	}
+
+	return header
+}
+
+// RequestPlan describes a request without sending it.
+type RequestPlan struct {
+	Method  string      `json:"method"`
+	Url     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Plan builds the request Do would send with vars and describes it, with the
+// values of secret headers and of the api key header replaced by Redacted.
+func (c *Client) Plan(vars map[string]any) (*RequestPlan, error) {
+	req, err := c.NewRequest(context.Background(), vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// The url as rendered, since req.URL would escape placeholders.
+	url, err := templating.Render(c.config.url, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &RequestPlan{
+		Method:  req.Method,
+		Url:     url,
+		Headers: req.Header,
+	}
+
+	if req.Host != "" && req.Host != req.URL.Host {
+		plan.Headers.Set("Host", req.Host)
+	}
+	redactHeaders(plan.Headers, c.apiKeyHeader(vars))
+
+	if req.Body != nil {
+		defer req.Body.Close()
+
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read body for %s request to %s: %w", req.Method, plan.Url, err)
+		}
+
+		plan.Body = string(body)
+	}
+
+	return plan, nil
 }
@@ -0,0 +1,217 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+)
+
+func newEchoServer(t *testing.T, received chan<- string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		data, _ := io.ReadAll(req.Body)
+		received <- string(data)
+		res.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestClientDoMethods(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("X-Method", req.Method)
+		res.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	methods := []string{
+		http.MethodGet,
+		http.MethodPost,
+		http.MethodPut,
+		http.MethodPatch,
+		http.MethodDelete,
+		http.MethodHead,
+		http.MethodOptions,
+		"PURGE",
+	}
+
+	for _, method := range methods {
+		t.Run(method, func(t *testing.T) {
+			client := api.NewClient(api.NewClientParams(method, server.URL, "application/json", api.NewBody(json.RawMessage(`{}`))))
+
+			resp, err := client.Do(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("Do() unexpected error: %v", err)
+			}
+			resp.Body.Close()
+
+			if gotMethod := resp.Header.Get("X-Method"); gotMethod != method {
+				t.Errorf("Do() sent method %q, want %q", gotMethod, method)
+			}
+		})
+	}
+}
+
+func TestClientConfigMethodOrDefault(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		def      string
+		expected string
+	}{
+		{name: "EmptyUsesDefault", method: "", def: http.MethodPost, expected: http.MethodPost},
+		{name: "LowerCaseIsUpperCased", method: "patch", def: http.MethodPost, expected: http.MethodPatch},
+		{name: "CustomVerb", method: "PURGE", def: http.MethodGet, expected: "PURGE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &api.ClientConfig{Method: tt.method}
+			if result := config.MethodOrDefault(tt.def); result != tt.expected {
+				t.Errorf("MethodOrDefault() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidMethod(t *testing.T) {
+	tests := []struct {
+		method   string
+		expected bool
+	}{
+		{method: "GET", expected: true},
+		{method: "PROPFIND", expected: true},
+		{method: "", expected: false},
+		{method: "GET POST", expected: false},
+		{method: "GET/1", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			if result := api.ValidMethod(tt.method); result != tt.expected {
+				t.Errorf("ValidMethod(%q) = %v, want %v", tt.method, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClientHeadersAndAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("X-Got-Tenant", req.Header.Get("X-Tenant"))
+		res.Header().Set("X-Got-Authorization", req.Header.Get("Authorization"))
+		res.Header().Set("X-Got-Host", req.Host)
+		res.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &api.ClientConfig{
+		Url: server.URL,
+		Headers: map[string]string{
+			"X-Tenant": "acme",
+			"Host":     "orders.internal",
+		},
+		Auth: &api.AuthConfig{Type: "bearer", Token: "abc123"},
+	}
+
+	params, err := api.NewClientParamsFromConfig(config, http.MethodGet)
+	if err != nil {
+		t.Fatalf("NewClientParamsFromConfig() unexpected error: %v", err)
+	}
+
+	resp, err := api.NewClient(params).Do(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Do() unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	expected := map[string]string{
+		"X-Got-Tenant":        "acme",
+		"X-Got-Authorization": "Bearer abc123",
+		"X-Got-Host":          "orders.internal",
+	}
+	for k, v := range expected {
+		if got := resp.Header.Get(k); got != v {
+			t.Errorf("server saw %s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestNewClientParamsFromConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *api.ClientConfig
+	}{
+		{name: "InvalidMethod", config: &api.ClientConfig{Method: "GET POST", Url: "http://localhost"}},
+		{name: "InvalidAuth", config: &api.ClientConfig{Url: "http://localhost", Auth: &api.AuthConfig{Type: "bearer"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := api.NewClientParamsFromConfig(tt.config, http.MethodGet); err == nil {
+				t.Errorf("NewClientParamsFromConfig() expected error but got none")
+			}
+		})
+	}
+}
+
+func TestClientPlan(t *testing.T) {
+	config := &api.ClientConfig{
+		Url:     "http://localhost/orders/{{.id}}",
+		Headers: map[string]string{"X-Tenant": "{{.tenant}}"},
+		Auth:    &api.AuthConfig{Type: "bearer", Token: "{{.token}}"},
+		Body:    json.RawMessage(`{"total": "{{.total}}"}`),
+	}
+
+	params, err := api.NewClientParamsFromConfig(config, http.MethodPut)
+	if err != nil {
+		t.Fatalf("NewClientParamsFromConfig() unexpected error: %v", err)
+	}
+
+	vars := map[string]any{"id": "<id>", "tenant": "acme", "token": "abc123", "total": 42}
+	plan, err := api.NewClient(params).Plan(vars)
+	if err != nil {
+		t.Fatalf("Plan() unexpected error: %v", err)
+	}
+
+	if plan.Method != http.MethodPut || plan.Url != "http://localhost/orders/<id>" {
+		t.Errorf("Plan() = %s %s, want PUT http://localhost/orders/<id>", plan.Method, plan.Url)
+	}
+	if plan.Headers.Get("X-Tenant") != "acme" || plan.Headers.Get("Authorization") != api.Redacted {
+		t.Errorf("Plan() headers = %v", plan.Headers)
+	}
+	if plan.Body != `{"total":42}` {
+		t.Errorf("Plan() body = %s", plan.Body)
+	}
+
+	if _, err := api.NewClient(params).Plan(nil); err == nil {
+		t.Error("Plan() with missing variables returned no error")
+	}
+}
+
+func TestClientPlanAPIKeyHeader(t *testing.T) {
+	config := &api.ClientConfig{
+		Url:  "http://localhost/orders",
+		Auth: &api.AuthConfig{Type: "apiKey", Header: "X-Client-Id", Key: "{{.key}}"},
+	}
+
+	params, err := api.NewClientParamsFromConfig(config, http.MethodGet)
+	if err != nil {
+		t.Fatalf("NewClientParamsFromConfig() unexpected error: %v", err)
+	}
+
+	plan, err := api.NewClient(params).Plan(map[string]any{"key": "abc123"})
+	if err != nil {
+		t.Fatalf("Plan() unexpected error: %v", err)
+	}
+
+	if got := plan.Headers.Get("X-Client-Id"); got != api.Redacted {
+		t.Errorf("Plan() header X-Client-Id = %q, want %q", got, api.Redacted)
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Redacted replaces a secret in a config that is written to a report.
+const Redacted = "[redacted]"
+
+var secretHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+}
+
+var secretHeaderWords = []string{"token", "secret", "password", "key", "auth", "session"}
+
+// SecretHeader reports whether a header with this name is likely to carry
+// credentials, such as Authorization, Cookie or X-API-Key.
+func SecretHeader(name string) bool {
+	name = strings.ToLower(name)
+	if secretHeaders[name] {
+		return true
+	}
+
+	for _, word := range secretHeaderWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactHeaders replaces the values of the secret headers in header, along
+// with those of any of the extra headers.
+func redactHeaders(header http.Header, extra ...string) {
+	for k, values := range header {
+		if !SecretHeader(k) && !slices.ContainsFunc(extra, func(name string) bool { return strings.EqualFold(name, k) }) {
+			continue
+		}
+
+		for i := range values {
+			values[i] = Redacted
+		}
+	}
+}
+
+// Redacted returns a copy of the config with credentials, secret headers, the
+// client key and any proxy password replaced, so it can be shared in a report.
+func (c *ClientConfig) Redacted() *ClientConfig {
+	if c == nil {
+		return nil
+	}
+
+	redacted := *c
+	redacted.Auth = c.Auth.Redacted()
+	redacted.Transport = c.Transport.Redacted()
+
+	if c.Headers != nil {
+		redacted.Headers = maps.Clone(c.Headers)
+		for k := range redacted.Headers {
+			if SecretHeader(k) {
+				redacted.Headers[k] = Redacted
+			}
+		}
+	}
+
+	return &redacted
+}
+
+// Redacted returns a copy of the config with the token, password and key
+// replaced.
+func (c *AuthConfig) Redacted() *AuthConfig {
+	if c == nil {
+		return nil
+	}
+
+	redacted := *c
+	for _, field := range []*string{&redacted.Token, &redacted.Password, &redacted.Key} {
+		if *field != "" {
+			*field = Redacted
+		}
+	}
+
+	return &redacted
+}
+
+// Redacted returns a copy of the config with the client key and the password
+// of the proxy replaced.
+func (c *TransportConfig) Redacted() *TransportConfig {
+	if c == nil {
+		return nil
+	}
+
+	redacted := *c
+	if c.ClientKey != "" {
+		redacted.ClientKey = Redacted
+	}
+
+	if proxy, err := url.Parse(c.Proxy); err == nil && proxy.User != nil {
+		redacted.Proxy = proxy.Redacted()
+	}
+
+	return &redacted
+}
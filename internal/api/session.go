@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"sync"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/assert"
+)
+
+// Session is the state a single simulated user keeps between requests. It has
+// a cookie jar of its own, and a connection pool of its own for every
+// transport its requests are sent with, so users never share cookies or
+// connections.
+type Session struct {
+	jar     http.CookieJar
+	mutex   sync.Mutex
+	clients map[*http.Client]*http.Client
+}
+
+func NewSession() *Session {
+	jar, err := cookiejar.New(nil)
+	assert.NoError(err, "Session cookie jar must be created")
+
+	return &Session{
+		jar:     jar,
+		clients: make(map[*http.Client]*http.Client),
+	}
+}
+
+// httpClient returns the session's copy of base, with the session's cookie
+// jar and a clone of base's transport.
+func (s *Session) httpClient(base *http.Client) *http.Client {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if client, ok := s.clients[base]; ok {
+		return client
+	}
+
+	client := &http.Client{
+		Transport:     cloneTransport(base.Transport),
+		CheckRedirect: base.CheckRedirect,
+		Jar:           s.jar,
+		Timeout:       base.Timeout,
+	}
+	s.clients[base] = client
+
+	return client
+}
+
+// Close closes the idle connections of every transport the session has used.
+func (s *Session) Close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, client := range s.clients {
+		client.CloseIdleConnections()
+	}
+}
+
+// cloneTransport returns a copy of rt with an empty connection pool. A
+// transport that is not an *http.Transport can not be copied and is shared.
+func cloneTransport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	if transport, ok := rt.(*http.Transport); ok {
+		return transport.Clone()
+	}
+
+	return rt
+}
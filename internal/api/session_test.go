@@ -0,0 +1,109 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+)
+
+func TestSessionCookies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/login" {
+			http.SetCookie(res, &http.Cookie{Name: "session", Value: req.URL.Query().Get("user")})
+			return
+		}
+
+		cookie, err := req.Cookie("session")
+		if err != nil {
+			res.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		res.Write([]byte(cookie.Value))
+	}))
+	defer server.Close()
+
+	login := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+"/login?user={{.user}}", "", nil))
+	profile := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+"/profile", "", nil))
+
+	send := func(session *api.Session, client *api.Client, vars map[string]any) *http.Response {
+		t.Helper()
+
+		req, err := client.NewRequest(context.Background(), vars)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := client.SendWith(session, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+
+		return resp
+	}
+
+	alice, bob := api.NewSession(), api.NewSession()
+	defer alice.Close()
+	defer bob.Close()
+
+	send(alice, login, map[string]any{"user": "alice"})
+	send(bob, login, map[string]any{"user": "bob"})
+
+	tests := []struct {
+		name     string
+		session  *api.Session
+		expected int
+	}{
+		{name: "Alice", session: alice, expected: http.StatusOK},
+		{name: "Bob", session: bob, expected: http.StatusOK},
+		{name: "NewSession", session: api.NewSession(), expected: http.StatusUnauthorized},
+		{name: "NoSession", session: nil, expected: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := send(tt.session, profile, nil); resp.StatusCode != tt.expected {
+				t.Errorf("SendWith() status = %d, want %d", resp.StatusCode, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSessionConnections(t *testing.T) {
+	var mu sync.Mutex
+	remotes := map[string]bool{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		remotes[req.RemoteAddr] = true
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := api.NewClient(api.NewClientParams(http.MethodGet, server.URL, "", nil))
+	sessions := []*api.Session{api.NewSession(), api.NewSession()}
+	for _, session := range sessions {
+		defer session.Close()
+
+		for range 3 {
+			req, err := client.NewRequest(context.Background(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := client.SendWith(session, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		}
+	}
+
+	if len(remotes) != len(sessions) {
+		t.Errorf("requests came over %d connection(s), want one per session", len(remotes))
+	}
+}
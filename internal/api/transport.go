@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+)
+
+type TransportConfig struct {
+	ConnectTimeout      duration.Duration `json:"connectTimeout,omitempty"`
+	ReadTimeout         duration.Duration `json:"readTimeout,omitempty"`
+	Timeout             duration.Duration `json:"timeout,omitempty"`
+	CACert              string            `json:"caCert,omitempty"`
+	InsecureSkipVerify  bool              `json:"insecureSkipVerify,omitempty"`
+	ClientCert          string            `json:"clientCert,omitempty"`
+	ClientKey           string            `json:"clientKey,omitempty"`
+	Proxy               string            `json:"proxy,omitempty"`
+	Resolve             []string          `json:"resolve,omitempty"`
+	HTTP2               *bool             `json:"http2,omitempty"`
+	H2C                 bool              `json:"h2c,omitempty"`
+	DisableKeepAlives   bool              `json:"disableKeepAlives,omitempty"`
+	MaxIdleConns        int               `json:"maxIdleConns,omitempty"`
+	MaxIdleConnsPerHost int               `json:"maxIdleConnsPerHost,omitempty"`
+	MaxConnsPerHost     int               `json:"maxConnsPerHost,omitempty"`
+	IdleConnTimeout     duration.Duration `json:"idleConnTimeout,omitempty"`
+}
+
+// resolvePaths joins the relative paths of the certificates and key to dir.
+func (c *TransportConfig) resolvePaths(dir string) {
+	if c == nil {
+		return
+	}
+
+	for _, path := range []*string{&c.CACert, &c.ClientCert, &c.ClientKey} {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+}
+
+// NewHTTPClient builds an http.Client for config. Options left unset keep the
+// defaults of http.DefaultTransport, and a nil config returns
+// http.DefaultClient.
+func NewHTTPClient(config *TransportConfig) (*http.Client, error) {
+	if config == nil {
+		return http.DefaultClient, nil
+	}
+
+	if config.ConnectTimeout < 0 || config.ReadTimeout < 0 || config.Timeout < 0 || config.IdleConnTimeout < 0 {
+		return nil, fmt.Errorf("transport timeouts can not be negative")
+	}
+
+	if config.MaxIdleConns < 0 || config.MaxIdleConnsPerHost < 0 || config.MaxConnsPerHost < 0 {
+		return nil, fmt.Errorf("transport connection limits can not be negative")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if config.ConnectTimeout > 0 {
+		dialer.Timeout = config.ConnectTimeout.Std()
+		transport.TLSHandshakeTimeout = config.ConnectTimeout.Std()
+	}
+
+	overrides, err := parseResolve(config.Resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if override, ok := overrides[addr]; ok {
+			addr = override
+		}
+
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	if config.ReadTimeout > 0 {
+		transport.ResponseHeaderTimeout = config.ReadTimeout.Std()
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	if config.Proxy != "" {
+		proxy, err := url.Parse(config.Proxy)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy url %q", config.Proxy)
+		}
+
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	http2 := config.HTTP2 == nil || *config.HTTP2
+	protocols := &http.Protocols{}
+	protocols.SetHTTP1(!config.H2C)
+	protocols.SetHTTP2(http2)
+	protocols.SetUnencryptedHTTP2(config.H2C)
+	transport.Protocols = protocols
+	transport.ForceAttemptHTTP2 = http2
+
+	transport.DisableKeepAlives = config.DisableKeepAlives
+	if config.MaxIdleConns > 0 {
+		transport.MaxIdleConns = config.MaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+	if config.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = config.MaxConnsPerHost
+	}
+	if config.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = config.IdleConnTimeout.Std()
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   config.Timeout.Std(),
+	}, nil
+}
+
+func newTLSConfig(config *TransportConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if config.CACert != "" {
+		pem, err := os.ReadFile(config.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle %q: %w", config.CACert, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %q", config.CACert)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if (config.ClientCert == "") != (config.ClientKey == "") {
+		return nil, fmt.Errorf("clientCert and clientKey must be set together")
+	}
+
+	if config.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCert, config.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// parseResolve turns curl style "host:port:address" entries into a map of
+// dial address overrides.
+func parseResolve(entries []string) (map[string]string, error) {
+	overrides := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid resolve entry %q, expected host:port:address", entry)
+		}
+
+		address := strings.TrimSuffix(strings.TrimPrefix(parts[2], "["), "]")
+		if net.ParseIP(address) == nil {
+			return nil, fmt.Errorf("invalid resolve entry %q, %q is not an IP address", entry, address)
+		}
+
+		overrides[net.JoinHostPort(parts[0], parts[1])] = net.JoinHostPort(address, parts[1])
+	}
+
+	return overrides, nil
+}
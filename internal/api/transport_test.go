@@ -0,0 +1,201 @@
+package api_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+)
+
+func doGet(t *testing.T, config *api.ClientConfig) (*http.Response, error) {
+	t.Helper()
+
+	params, err := api.NewClientParamsFromConfig(config, http.MethodGet)
+	if err != nil {
+		t.Fatalf("NewClientParamsFromConfig() unexpected error: %v", err)
+	}
+
+	resp, err := api.NewClient(params).Do(context.Background(), nil)
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	return resp, err
+}
+
+func TestTransportTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		res.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		transport   *api.TransportConfig
+		expectError bool
+	}{
+		{name: "OverallTimeout", transport: &api.TransportConfig{Timeout: duration.Duration(50 * time.Millisecond)}, expectError: true},
+		{name: "ReadTimeout", transport: &api.TransportConfig{ReadTimeout: duration.Duration(50 * time.Millisecond)}, expectError: true},
+		{name: "GenerousTimeout", transport: &api.TransportConfig{Timeout: duration.Duration(5 * time.Second)}, expectError: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := doGet(t, &api.ClientConfig{Url: server.URL, Transport: tt.transport})
+			if tt.expectError && err == nil {
+				t.Errorf("Do() expected timeout error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Do() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestTransportTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o644); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		transport   *api.TransportConfig
+		expectError bool
+	}{
+		{name: "UnknownAuthority", transport: &api.TransportConfig{}, expectError: true},
+		{name: "CustomCA", transport: &api.TransportConfig{CACert: caFile}, expectError: false},
+		{name: "InsecureSkipVerify", transport: &api.TransportConfig{InsecureSkipVerify: true}, expectError: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := doGet(t, &api.ClientConfig{Url: server.URL, Transport: tt.transport})
+			if tt.expectError && err == nil {
+				t.Errorf("Do() expected TLS error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Do() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestTransportResolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("X-Got-Host", req.Host)
+		res.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	config := &api.ClientConfig{
+		Url:       "http://orders.example.invalid:" + port,
+		Transport: &api.TransportConfig{Resolve: []string{"orders.example.invalid:" + port + ":127.0.0.1"}},
+	}
+
+	resp, err := doGet(t, config)
+	if err != nil {
+		t.Fatalf("Do() unexpected error: %v", err)
+	}
+
+	if got := resp.Header.Get("X-Got-Host"); got != "orders.example.invalid:"+port {
+		t.Errorf("server saw host %q, want the original host", got)
+	}
+}
+
+func TestTransportProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("X-Proxied-Url", req.URL.String())
+		res.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	config := &api.ClientConfig{
+		Url:       "http://upstream.example.invalid/test",
+		Transport: &api.TransportConfig{Proxy: proxy.URL},
+	}
+
+	resp, err := doGet(t, config)
+	if err != nil {
+		t.Fatalf("Do() unexpected error: %v", err)
+	}
+
+	if got := resp.Header.Get("X-Proxied-Url"); got != config.Url {
+		t.Errorf("proxy saw url %q, want %q", got, config.Url)
+	}
+}
+
+func TestTransportH2C(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("X-Proto", req.Proto)
+		res.WriteHeader(http.StatusOK)
+	}))
+	server.Config.Protocols = &http.Protocols{}
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+
+	resp, err := doGet(t, &api.ClientConfig{Url: server.URL, Transport: &api.TransportConfig{H2C: true}})
+	if err != nil {
+		t.Fatalf("Do() unexpected error: %v", err)
+	}
+
+	if got := resp.Header.Get("X-Proto"); got != "HTTP/2.0" {
+		t.Errorf("server saw protocol %q, want HTTP/2.0", got)
+	}
+}
+
+func TestNewHTTPClientErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport *api.TransportConfig
+	}{
+		{name: "NegativeTimeout", transport: &api.TransportConfig{Timeout: duration.Duration(-time.Second)}},
+		{name: "NegativeConnLimit", transport: &api.TransportConfig{MaxIdleConns: -1}},
+		{name: "MissingCAFile", transport: &api.TransportConfig{CACert: "does-not-exist.pem"}},
+		{name: "CertWithoutKey", transport: &api.TransportConfig{ClientCert: "client.pem"}},
+		{name: "InvalidProxy", transport: &api.TransportConfig{Proxy: "://nope"}},
+		{name: "ResolveMissingAddress", transport: &api.TransportConfig{Resolve: []string{"example.com:443"}}},
+		{name: "ResolveNotAnIP", transport: &api.TransportConfig{Resolve: []string{"example.com:443:localhost"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := api.NewHTTPClient(tt.transport); err == nil {
+				t.Errorf("NewHTTPClient() expected error but got none")
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientDefaults(t *testing.T) {
+	client, err := api.NewHTTPClient(nil)
+	if err != nil || client != http.DefaultClient {
+		t.Errorf("NewHTTPClient(nil) = %v, %v, want http.DefaultClient", client, err)
+	}
+
+	client, err = api.NewHTTPClient(&api.TransportConfig{Resolve: []string{"example.com:443:[::1]"}})
+	if err != nil {
+		t.Fatalf("NewHTTPClient() unexpected error: %v", err)
+	}
+	if client.Timeout != 0 {
+		t.Errorf("NewHTTPClient() timeout = %v, want no overall timeout by default", client.Timeout)
+	}
+}
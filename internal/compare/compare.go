@@ -0,0 +1,306 @@
+package compare
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+)
+
+// Tolerances decide how much worse a run may get before it counts as a
+// regression. Latency and TimeToConsistency are relative, so 0.1 allows a
+// 10% increase, while ErrorRate is absolute, so 0.01 allows one more failed
+// request in a hundred. Increases in latency or time to consistency smaller
+// than NoiseFloor are never regressions.
+type Tolerances struct {
+	Latency           float64
+	ErrorRate         float64
+	TimeToConsistency float64
+	NoiseFloor        time.Duration
+}
+
+func DefaultTolerances() Tolerances {
+	return Tolerances{
+		Latency:           0.1,
+		ErrorRate:         0.01,
+		TimeToConsistency: 0.2,
+		NoiseFloor:        time.Millisecond,
+	}
+}
+
+// Delta is the change of a single metric between two runs.
+type Delta struct {
+	Simulation string
+	Metric     string
+	Name       string
+	Baseline   string
+	Current    string
+	Change     string
+	Regression bool
+}
+
+// Comparison is every delta between two runs. Unmatched lists what only one
+// of the runs had, and so could not be compared.
+type Comparison struct {
+	Deltas    []*Delta
+	Unmatched []string
+}
+
+// Regressed reports whether any metric regressed.
+func (c *Comparison) Regressed() bool {
+	for _, delta := range c.Deltas {
+		if delta.Regression {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Compare compares the simulations of current with the simulations of the
+// same name in baseline.
+func Compare(baseline []*simulation.SimulationResult, current []*simulation.SimulationResult, t Tolerances) *Comparison {
+	c := &Comparison{}
+
+	currentByName := make(map[string]*simulation.SimulationResult, len(current))
+	for _, result := range current {
+		currentByName[result.Name] = result
+	}
+
+	matched := make(map[string]bool, len(baseline))
+	for _, base := range baseline {
+		cur, ok := currentByName[base.Name]
+		if !ok {
+			c.Unmatched = append(c.Unmatched, fmt.Sprintf("simulation %q is only in the baseline", base.Name))
+			continue
+		}
+
+		matched[base.Name] = true
+		c.compareSimulation(base, cur, t)
+	}
+
+	for _, result := range current {
+		if !matched[result.Name] {
+			c.Unmatched = append(c.Unmatched, fmt.Sprintf("simulation %q is only in the current run", result.Name))
+		}
+	}
+
+	return c
+}
+
+func (c *Comparison) compareSimulation(base *simulation.SimulationResult, cur *simulation.SimulationResult, t Tolerances) {
+	c.compareRate(base.Name, "error rate", "all requests", base.Summary.FailedRequests, base.Summary.Requests, cur.Summary.FailedRequests, cur.Summary.Requests, t.ErrorRate)
+
+	curTargets := make(map[string]*simulation.TargetResult, len(cur.Targets))
+	for _, target := range cur.Targets {
+		curTargets[target.Name] = target
+	}
+	for _, target := range base.Targets {
+		if curTarget, ok := curTargets[target.Name]; ok {
+			c.compareRate(base.Name, "error rate", target.Name, target.FailedRequests, target.Requests, curTarget.FailedRequests, curTarget.Requests, t.ErrorRate)
+		}
+	}
+
+	if base.Summary.Monitors > 0 || cur.Summary.Monitors > 0 {
+		c.compareRate(base.Name, "monitor failure rate", "all monitors", base.Summary.FailedMonitors, base.Summary.Monitors, cur.Summary.FailedMonitors, cur.Summary.Monitors, t.ErrorRate)
+	}
+
+	curLatency := make(map[string]*simulation.LatencyResult, len(cur.Latency))
+	for _, l := range cur.Latency {
+		curLatency[latencyKey(l)] = l
+	}
+	for _, l := range base.Latency {
+		curL, ok := curLatency[latencyKey(l)]
+		if !ok {
+			c.Unmatched = append(c.Unmatched, fmt.Sprintf("%s latency of %s in %q is only in the baseline", l.Kind, latencyName(l), base.Name))
+			continue
+		}
+
+		stats := []struct {
+			name    string
+			base    time.Duration
+			current time.Duration
+		}{
+			{name: "p50", base: l.P50.Std(), current: curL.P50.Std()},
+			{name: "p90", base: l.P90.Std(), current: curL.P90.Std()},
+			{name: "p95", base: l.P95.Std(), current: curL.P95.Std()},
+			{name: "p99", base: l.P99.Std(), current: curL.P99.Std()},
+		}
+		for _, stat := range stats {
+			c.compareDuration(base.Name, l.Kind+" latency "+stat.name, latencyName(l), stat.base, stat.current, t.Latency, t.NoiseFloor)
+		}
+	}
+
+	baseConsistency, curConsistency := timeToConsistency(base), timeToConsistency(cur)
+	for _, m := range baseConsistency {
+		curM, ok := find(curConsistency, m.name)
+		if !ok {
+			c.Unmatched = append(c.Unmatched, fmt.Sprintf("monitor %s in %q is only in the baseline", m.name, base.Name))
+			continue
+		}
+
+		if m.count == 0 || curM.count == 0 {
+			c.Unmatched = append(c.Unmatched, fmt.Sprintf("time to consistency of %s in %q as the monitor was never satisfied in %s", m.name, base.Name, neverSatisfied(m.count, curM.count)))
+			continue
+		}
+
+		c.compareDuration(base.Name, "time to consistency mean", m.name, m.mean(), curM.mean(), t.TimeToConsistency, t.NoiseFloor)
+		c.compareDuration(base.Name, "time to consistency max", m.name, m.max, curM.max, t.TimeToConsistency, t.NoiseFloor)
+	}
+
+	for _, m := range curConsistency {
+		if _, ok := find(baseConsistency, m.name); !ok {
+			c.Unmatched = append(c.Unmatched, fmt.Sprintf("monitor %s in %q is only in the current run", m.name, base.Name))
+		}
+	}
+}
+
+func (c *Comparison) compareRate(sim string, metric string, name string, baseFailed int, baseTotal int, curFailed int, curTotal int, tolerance float64) {
+	baseRate, curRate := rate(baseFailed, baseTotal), rate(curFailed, curTotal)
+	c.Deltas = append(c.Deltas, &Delta{
+		Simulation: sim,
+		Metric:     metric,
+		Name:       name,
+		Baseline:   fmt.Sprintf("%.2f%%", baseRate*100),
+		Current:    fmt.Sprintf("%.2f%%", curRate*100),
+		Change:     fmt.Sprintf("%+.2fpp", (curRate-baseRate)*100),
+		Regression: curRate-baseRate > tolerance,
+	})
+}
+
+func (c *Comparison) compareDuration(sim string, metric string, name string, base time.Duration, cur time.Duration, tolerance float64, floor time.Duration) {
+	change := "n/a"
+	if base > 0 {
+		change = fmt.Sprintf("%+.1f%%", (float64(cur)/float64(base)-1)*100)
+	}
+
+	c.Deltas = append(c.Deltas, &Delta{
+		Simulation: sim,
+		Metric:     metric,
+		Name:       name,
+		Baseline:   base.String(),
+		Current:    cur.String(),
+		Change:     change,
+		Regression: cur-base > floor && float64(cur) > float64(base)*(1+tolerance),
+	})
+}
+
+// WriteText writes every delta as a table, marking regressions.
+func (c *Comparison) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SIMULATION\tMETRIC\tNAME\tBASELINE\tCURRENT\tCHANGE\t")
+	for _, delta := range c.Deltas {
+		verdict := ""
+		if delta.Regression {
+			verdict = "REGRESSION"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", delta.Simulation, delta.Metric, delta.Name, delta.Baseline, delta.Current, delta.Change, verdict)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, unmatched := range c.Unmatched {
+		if _, err := fmt.Fprintf(w, "not compared: %s\n", unmatched); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type consistency struct {
+	name  string
+	count int
+	total time.Duration
+	max   time.Duration
+}
+
+func (c *consistency) mean() time.Duration {
+	return c.total / time.Duration(c.count)
+}
+
+// timeToConsistency collects how long each monitor took to see its expected
+// response, over every time it was satisfied. It falls back to the monitors
+// kept in the result for results written before consistency was recorded.
+func timeToConsistency(result *simulation.SimulationResult) []*consistency {
+	var monitors []*consistency
+	monitor := func(name string) *consistency {
+		c, ok := find(monitors, name)
+		if !ok {
+			c = &consistency{name: name}
+			monitors = append(monitors, c)
+		}
+
+		return c
+	}
+
+	if len(result.Consistency) > 0 {
+		for _, m := range result.Consistency {
+			c := monitor(m.Target + "/" + m.Name)
+			c.count += m.Satisfied
+			c.total += m.Mean.Std() * time.Duration(m.Satisfied)
+			c.max = max(c.max, m.Max.Std())
+		}
+
+		return monitors
+	}
+
+	for _, m := range result.Monitors {
+		c := monitor(m.Target + "/" + m.Name)
+		if m.Satisfied() {
+			c.count++
+			c.total += m.TimeToSuccess.Std()
+			c.max = max(c.max, m.TimeToSuccess.Std())
+		}
+	}
+
+	return monitors
+}
+
+// neverSatisfied names the runs a monitor was never satisfied in, given how
+// many times it was in the baseline and the current run.
+func neverSatisfied(base int, cur int) string {
+	switch {
+	case base == 0 && cur == 0:
+		return "either run"
+	case base == 0:
+		return "the baseline"
+	default:
+		return "the current run"
+	}
+}
+
+func find(monitors []*consistency, name string) (*consistency, bool) {
+	for _, m := range monitors {
+		if m.name == name {
+			return m, true
+		}
+	}
+
+	return nil, false
+}
+
+func latencyKey(l *simulation.LatencyResult) string {
+	return l.Kind + "\x00" + latencyName(l)
+}
+
+func latencyName(l *simulation.LatencyResult) string {
+	if l.Target != "" {
+		return l.Target + "/" + l.Name
+	}
+
+	return l.Name
+}
+
+func rate(failed int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(failed) / float64(total)
+}
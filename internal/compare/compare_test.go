@@ -0,0 +1,173 @@
+package compare_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/compare"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/monitor"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+)
+
+func newResult(failed int, p95 time.Duration, timeToSuccess time.Duration) *simulation.SimulationResult {
+	summary := simulation.Summary{Requests: 100, FailedRequests: failed, Monitors: 1, SatisfiedMonitors: 1}
+	return &simulation.SimulationResult{
+		Name:    "Orders",
+		Summary: summary,
+		Targets: []*simulation.TargetResult{{Name: "orders", Summary: summary}},
+		Latency: []*simulation.LatencyResult{{
+			Kind: simulation.LatencyKindTarget,
+			Name: "orders",
+			P50:  duration.Duration(10 * time.Millisecond),
+			P90:  duration.Duration(20 * time.Millisecond),
+			P95:  duration.Duration(p95),
+			P99:  duration.Duration(50 * time.Millisecond),
+		}},
+		Monitors: []*simulation.MonitorResult{{
+			Target: "orders",
+			Result: &monitor.Result{Name: "status", Verdict: monitor.VerdictSatisfied, TimeToSuccess: duration.Duration(timeToSuccess)},
+		}},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := newResult(1, 30*time.Millisecond, time.Second)
+
+	tests := []struct {
+		name        string
+		current     *simulation.SimulationResult
+		regressions []string
+	}{
+		{
+			name:        "Unchanged",
+			current:     newResult(1, 30*time.Millisecond, time.Second),
+			regressions: nil,
+		},
+		{
+			name:        "Within tolerance",
+			current:     newResult(2, 32*time.Millisecond, 1100*time.Millisecond),
+			regressions: nil,
+		},
+		{
+			name:        "Improved",
+			current:     newResult(0, 10*time.Millisecond, 500*time.Millisecond),
+			regressions: nil,
+		},
+		{
+			name:        "Latency regressed",
+			current:     newResult(1, 40*time.Millisecond, time.Second),
+			regressions: []string{"target latency p95"},
+		},
+		{
+			name:        "Error rate regressed",
+			current:     newResult(5, 30*time.Millisecond, time.Second),
+			regressions: []string{"error rate", "error rate"},
+		},
+		{
+			name:        "Time to consistency regressed",
+			current:     newResult(1, 30*time.Millisecond, 2*time.Second),
+			regressions: []string{"time to consistency mean", "time to consistency max"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparison := compare.Compare([]*simulation.SimulationResult{baseline}, []*simulation.SimulationResult{tt.current}, compare.DefaultTolerances())
+
+			var regressions []string
+			for _, delta := range comparison.Deltas {
+				if delta.Regression {
+					regressions = append(regressions, delta.Metric)
+				}
+			}
+
+			if strings.Join(regressions, ",") != strings.Join(tt.regressions, ",") {
+				t.Errorf("regressions = %v, want %v", regressions, tt.regressions)
+			}
+			if comparison.Regressed() != (len(tt.regressions) > 0) {
+				t.Errorf("Regressed() = %v, want %v", comparison.Regressed(), len(tt.regressions) > 0)
+			}
+		})
+	}
+}
+
+func TestCompareNoiseFloor(t *testing.T) {
+	baseline := newResult(0, 100*time.Microsecond, time.Second)
+	current := newResult(0, 300*time.Microsecond, time.Second)
+
+	comparison := compare.Compare([]*simulation.SimulationResult{baseline}, []*simulation.SimulationResult{current}, compare.DefaultTolerances())
+	if comparison.Regressed() {
+		t.Errorf("an increase below the noise floor should not regress")
+	}
+}
+
+func TestCompareUnmatched(t *testing.T) {
+	baseline := newResult(0, 30*time.Millisecond, time.Second)
+	current := newResult(0, 30*time.Millisecond, time.Second)
+	current.Name = "Renamed"
+
+	comparison := compare.Compare([]*simulation.SimulationResult{baseline}, []*simulation.SimulationResult{current}, compare.DefaultTolerances())
+	if len(comparison.Deltas) != 0 || len(comparison.Unmatched) != 2 {
+		t.Errorf("comparison = %+v, want nothing compared and both simulations unmatched", comparison)
+	}
+
+	var sb strings.Builder
+	if err := comparison.WriteText(&sb); err != nil {
+		t.Fatalf("WriteText() error: %v", err)
+	}
+	if !strings.Contains(sb.String(), `simulation "Renamed" is only in the current run`) {
+		t.Errorf("WriteText() = %s", sb.String())
+	}
+}
+
+func TestCompareSampledConsistency(t *testing.T) {
+	sampled := func(timeToSuccess time.Duration, satisfied int) *simulation.SimulationResult {
+		result := newResult(0, 30*time.Millisecond, timeToSuccess)
+		result.Sampled = true
+		result.Monitors = nil
+		result.Consistency = []*simulation.ConsistencyResult{{
+			Target:    "orders",
+			Name:      "status",
+			Satisfied: satisfied,
+			Mean:      duration.Duration(timeToSuccess),
+			Max:       duration.Duration(timeToSuccess),
+		}}
+
+		return result
+	}
+
+	comparison := compare.Compare([]*simulation.SimulationResult{sampled(time.Second, 10)}, []*simulation.SimulationResult{sampled(2*time.Second, 10)}, compare.DefaultTolerances())
+
+	var regressions []string
+	for _, delta := range comparison.Deltas {
+		if delta.Regression {
+			regressions = append(regressions, delta.Metric)
+		}
+	}
+	if strings.Join(regressions, ",") != "time to consistency mean,time to consistency max" {
+		t.Errorf("regressions = %v, want time to consistency from the recorded consistency", regressions)
+	}
+
+	comparison = compare.Compare([]*simulation.SimulationResult{sampled(time.Second, 10)}, []*simulation.SimulationResult{sampled(0, 0)}, compare.DefaultTolerances())
+	if len(comparison.Unmatched) != 1 || !strings.Contains(comparison.Unmatched[0], "never satisfied in the current run") {
+		t.Errorf("unmatched = %v, want time to consistency reported as not compared", comparison.Unmatched)
+	}
+}
+
+func TestCompareUnmatchedMonitors(t *testing.T) {
+	baseline := newResult(0, 30*time.Millisecond, time.Second)
+	current := newResult(0, 30*time.Millisecond, time.Second)
+	current.Monitors[0].Name = "shipped"
+
+	comparison := compare.Compare([]*simulation.SimulationResult{baseline}, []*simulation.SimulationResult{current}, compare.DefaultTolerances())
+
+	expected := []string{
+		`monitor orders/status in "Orders" is only in the baseline`,
+		`monitor orders/shipped in "Orders" is only in the current run`,
+	}
+	if strings.Join(comparison.Unmatched, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unmatched = %v, want %v", comparison.Unmatched, expected)
+	}
+}
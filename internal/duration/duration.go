@@ -0,0 +1,80 @@
+package duration
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Duration is a time.Duration that is written in config files as a string
+// such as "250ms", "5s" or "1m30s".
+type Duration time.Duration
+
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"250ms\" or \"5s\", got %s", string(data))
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// OrSeconds is a Duration that can also be written as a whole number of
+// seconds, for config fields that were numbers of seconds before they took
+// strings. A fractional number could be meant as seconds or as a shorter
+// unit, so it is rejected along with strings without a unit.
+type OrSeconds Duration
+
+func (d OrSeconds) Std() time.Duration {
+	return time.Duration(d)
+}
+
+func (d OrSeconds) String() string {
+	return time.Duration(d).String()
+}
+
+func (d OrSeconds) MarshalJSON() ([]byte, error) {
+	return Duration(d).MarshalJSON()
+}
+
+func (d *OrSeconds) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return (*Duration)(d).UnmarshalJSON(data)
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("duration must be a string such as \"250ms\" or \"5s\", or a whole number of seconds, got %s", string(data))
+	}
+
+	seconds, err := n.Int64()
+	if err != nil {
+		return fmt.Errorf("duration %s is ambiguous, use a whole number of seconds or a string with a unit such as \"%ss\"", n, n)
+	}
+
+	if seconds > math.MaxInt64/int64(time.Second) || seconds < math.MinInt64/int64(time.Second) {
+		return fmt.Errorf("duration of %d seconds is too long", seconds)
+	}
+
+	*d = OrSeconds(time.Duration(seconds) * time.Second)
+	return nil
+}
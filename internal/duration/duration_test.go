@@ -0,0 +1,108 @@
+package duration_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+)
+
+func TestDurationUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    time.Duration
+		expectError bool
+	}{
+		{name: "Milliseconds", input: `"250ms"`, expected: 250 * time.Millisecond},
+		{name: "Seconds", input: `"5s"`, expected: 5 * time.Second},
+		{name: "Compound", input: `"1m30s"`, expected: 90 * time.Second},
+		{name: "MissingUnit", input: `"5"`, expectError: true},
+		{name: "Garbage", input: `"soon"`, expectError: true},
+		{name: "Object", input: `{}`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d duration.Duration
+			err := json.Unmarshal([]byte(tt.input), &d)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("UnmarshalJSON(%s) expected error but got none", tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("UnmarshalJSON(%s) unexpected error: %v", tt.input, err)
+			}
+			if d.Std() != tt.expected {
+				t.Errorf("UnmarshalJSON(%s) = %v, want %v", tt.input, d.Std(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestDurationMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(duration.Duration(1500 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("MarshalJSON() unexpected error: %v", err)
+	}
+
+	if string(data) != `"1.5s"` {
+		t.Errorf("MarshalJSON() = %s, want %q", string(data), "1.5s")
+	}
+}
+
+func TestOrSecondsUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    time.Duration
+		expectError bool
+	}{
+		{name: "Milliseconds", input: `"250ms"`, expected: 250 * time.Millisecond},
+		{name: "Compound", input: `"1m30s"`, expected: 90 * time.Second},
+		{name: "WholeSeconds", input: `5`, expected: 5 * time.Second},
+		{name: "Zero", input: `0`, expected: 0},
+		{name: "Fractional", input: `0.5`, expectError: true},
+		{name: "Exponent", input: `1e3`, expectError: true},
+		{name: "MissingUnit", input: `"5"`, expectError: true},
+		{name: "TooLong", input: `10000000000000`, expectError: true},
+		{name: "Object", input: `{}`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d duration.OrSeconds
+			err := json.Unmarshal([]byte(tt.input), &d)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("UnmarshalJSON(%s) expected error but got none", tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("UnmarshalJSON(%s) unexpected error: %v", tt.input, err)
+			}
+			if d.Std() != tt.expected {
+				t.Errorf("UnmarshalJSON(%s) = %v, want %v", tt.input, d.Std(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestOrSecondsMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(duration.OrSeconds(5 * time.Second))
+	if err != nil {
+		t.Fatalf("MarshalJSON() unexpected error: %v", err)
+	}
+
+	if string(data) != `"5s"` {
+		t.Errorf("MarshalJSON() = %s, want %q", string(data), "5s")
+	}
+}
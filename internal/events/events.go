@@ -0,0 +1,153 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Types of event emitted during a simulation run.
+const (
+	SimulationStart  = "simulation_start"
+	SimulationEnd    = "simulation_end"
+	RequestSent      = "request_sent"
+	RequestFailed    = "request_failed"
+	ResponseReceived = "response_received"
+	IterationDropped = "iteration_dropped"
+	MonitorPoll      = "monitor_poll"
+	MonitorSatisfied = "monitor_satisfied"
+	MonitorExhausted = "monitor_exhausted"
+	MonitorCancelled = "monitor_cancelled"
+	MonitorError     = "monitor_error"
+)
+
+// Event is a single thing that happened during a run. Data holds the details
+// that depend on the type of event.
+type Event struct {
+	Type         string         `json:"type"`
+	Time         time.Time      `json:"time"`
+	SimulationID string         `json:"simulationId,omitempty"`
+	Simulation   string         `json:"simulation,omitempty"`
+	Task         string         `json:"task,omitempty"`
+	Data         map[string]any `json:"data,omitempty"`
+}
+
+// Sink receives every event an Emitter emits. Write may be called from many
+// goroutines at once.
+type Sink interface {
+	Write(event *Event) error
+}
+
+// NDJSONWriter is a Sink writing each event as a line of JSON.
+type NDJSONWriter struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+}
+
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	return &NDJSONWriter{encoder: encoder}
+}
+
+func (w *NDJSONWriter) Write(event *Event) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.encoder.Encode(event)
+}
+
+// Emitter stamps events with the simulation and task they came from before
+// handing them to a Sink. A nil Emitter drops every event, so code can emit
+// without checking whether anyone is listening.
+type Emitter struct {
+	sink         Sink
+	simulationID string
+	simulation   string
+	task         string
+	data         map[string]any
+	logger       *slog.Logger
+}
+
+func NewEmitter(sink Sink) *Emitter {
+	return &Emitter{
+		sink:   sink,
+		logger: slog.Default().With("area", "Events"),
+	}
+}
+
+// WithSimulation returns an Emitter that stamps events with the id and name
+// of a simulation.
+func (e *Emitter) WithSimulation(id string, name string) *Emitter {
+	if e == nil {
+		return nil
+	}
+
+	child := *e
+	child.simulationID = id
+	child.simulation = name
+
+	return &child
+}
+
+// WithTask returns an Emitter that stamps events with the name of a task and
+// adds data to every event.
+func (e *Emitter) WithTask(name string, data map[string]any) *Emitter {
+	if e == nil {
+		return nil
+	}
+
+	child := *e
+	child.task = name
+	child.data = maps.Clone(e.data)
+	if child.data == nil {
+		child.data = make(map[string]any, len(data))
+	}
+	maps.Copy(child.data, data)
+
+	return &child
+}
+
+// Emit sends an event of type typ, with data added to the emitter's own.
+func (e *Emitter) Emit(typ string, data map[string]any) {
+	if e == nil || e.sink == nil {
+		return
+	}
+
+	event := &Event{
+		Type:         typ,
+		Time:         time.Now(),
+		SimulationID: e.simulationID,
+		Simulation:   e.simulation,
+		Task:         e.task,
+	}
+
+	if len(e.data) > 0 || len(data) > 0 {
+		event.Data = make(map[string]any, len(e.data)+len(data))
+		maps.Copy(event.Data, e.data)
+		maps.Copy(event.Data, data)
+	}
+
+	if err := e.sink.Write(event); err != nil {
+		e.logger.Error("Unable to write event", "type", typ, "error", err)
+	}
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying e, so anything running under ctx
+// can emit events with FromContext.
+func NewContext(ctx context.Context, e *Emitter) context.Context {
+	return context.WithValue(ctx, contextKey{}, e)
+}
+
+// FromContext returns the Emitter carried by ctx, or nil when there is none.
+func FromContext(ctx context.Context) *Emitter {
+	e, _ := ctx.Value(contextKey{}).(*Emitter)
+	return e
+}
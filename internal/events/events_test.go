@@ -0,0 +1,62 @@
+package events_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/events"
+)
+
+func TestEmitterNDJSON(t *testing.T) {
+	var sb strings.Builder
+	root := events.NewEmitter(events.NewNDJSONWriter(&sb))
+	sim := root.WithSimulation("1234", "Orders")
+	task := sim.WithTask("Orders 1234 reads", map[string]any{"target": "reads", "client": 2})
+
+	sim.Emit(events.SimulationStart, nil)
+	task.Emit(events.RequestSent, map[string]any{"url": "http://localhost/<id>"})
+	task.WithTask("Orders 1234 reads", map[string]any{"client": 3}).Emit(events.ResponseReceived, nil)
+
+	var got []events.Event
+	scanner := bufio.NewScanner(strings.NewReader(sb.String()))
+	for scanner.Scan() {
+		var event events.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("line %q is not json: %v", scanner.Text(), err)
+		}
+		got = append(got, event)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("got %d events, want 3:\n%s", len(got), sb.String())
+	}
+
+	if got[0].Type != events.SimulationStart || got[0].SimulationID != "1234" || got[0].Simulation != "Orders" || got[0].Task != "" || got[0].Data != nil {
+		t.Errorf("simulation event = %+v", got[0])
+	}
+	if got[0].Time.IsZero() {
+		t.Errorf("simulation event has no time")
+	}
+
+	if got[1].Task != "Orders 1234 reads" || got[1].Data["target"] != "reads" || got[1].Data["client"] != 2.0 || got[1].Data["url"] != "http://localhost/<id>" {
+		t.Errorf("task event = %+v", got[1])
+	}
+
+	if got[2].Data["client"] != 3.0 || got[2].Data["target"] != "reads" {
+		t.Errorf("nested task event = %+v, want client overridden and target kept", got[2])
+	}
+	if !strings.Contains(sb.String(), "<id>") {
+		t.Errorf("events escaped html: %s", sb.String())
+	}
+}
+
+func TestNilEmitter(t *testing.T) {
+	var e *events.Emitter
+	e.WithSimulation("1234", "Orders").WithTask("task", nil).Emit(events.SimulationStart, nil)
+
+	if events.FromContext(t.Context()) != nil {
+		t.Errorf("FromContext() on a context without an emitter should be nil")
+	}
+}
@@ -0,0 +1,223 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+)
+
+// Rule describes where a single value is taken from in a response. Exactly
+// one of JSONPath, JMESPath, Header or Regex must be set. Group selects the
+// regex capture group and defaults to the first group, or the whole match
+// when the expression has no groups.
+type Rule struct {
+	JSONPath string `json:"jsonPath,omitempty"`
+	JMESPath string `json:"jmesPath,omitempty"`
+	Header   string `json:"header,omitempty"`
+	Regex    string `json:"regex,omitempty"`
+	Group    *int   `json:"group,omitempty"`
+}
+
+// Rules maps variable names to the rule that extracts them.
+type Rules map[string]*Rule
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (r *Rule) Validate() error {
+	_, err := r.compile()
+	return err
+}
+
+func (r *Rule) sources() int {
+	count := 0
+	for _, s := range []string{r.JSONPath, r.JMESPath, r.Header, r.Regex} {
+		if s != "" {
+			count++
+		}
+	}
+
+	return count
+}
+
+type compiledRule struct {
+	path   *Path
+	header string
+	regex  *regexp.Regexp
+	group  int
+}
+
+func (r *Rule) compile() (*compiledRule, error) {
+	if r == nil {
+		return nil, fmt.Errorf("extract rule can not be empty")
+	}
+
+	if r.sources() != 1 {
+		return nil, fmt.Errorf("extract rule must set exactly one of jsonPath, jmesPath, header or regex")
+	}
+
+	switch {
+	case r.JSONPath != "":
+		path, err := ParseJSONPath(r.JSONPath)
+		if err != nil {
+			return nil, err
+		}
+
+		return &compiledRule{path: path}, nil
+	case r.JMESPath != "":
+		path, err := ParseJMESPath(r.JMESPath)
+		if err != nil {
+			return nil, err
+		}
+
+		return &compiledRule{path: path}, nil
+	case r.Header != "":
+		return &compiledRule{header: r.Header}, nil
+	}
+
+	regex, err := regexp.Compile(r.Regex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %w", r.Regex, err)
+	}
+
+	group := 0
+	if regex.NumSubexp() > 0 {
+		group = 1
+	}
+	if r.Group != nil {
+		group = *r.Group
+	}
+
+	if group < 0 || group > regex.NumSubexp() {
+		return nil, fmt.Errorf("regex %q has no capture group %d", r.Regex, group)
+	}
+
+	return &compiledRule{regex: regex, group: group}, nil
+}
+
+func (c *compiledRule) apply(header http.Header, body []byte, decoded func() (any, error)) (any, error) {
+	switch {
+	case c.path != nil:
+		doc, err := decoded()
+		if err != nil {
+			return nil, err
+		}
+
+		value, ok := c.path.Evaluate(doc)
+		if !ok {
+			return nil, fmt.Errorf("path %s matched nothing in the response body", c.path)
+		}
+
+		return value, nil
+	case c.header != "":
+		values := header.Values(c.header)
+		if len(values) == 0 {
+			return nil, fmt.Errorf("response has no %s header", c.header)
+		}
+
+		return values[0], nil
+	}
+
+	match := c.regex.FindSubmatch(body)
+	if match == nil {
+		return nil, fmt.Errorf("regex %s matched nothing in the response body", c.regex)
+	}
+
+	return string(match[c.group]), nil
+}
+
+func (rules Rules) Validate() error {
+	var errs []error
+	for _, name := range rules.names() {
+		if !namePattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("%q is not a valid variable name", name))
+			continue
+		}
+
+		if err := rules[name].Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Apply runs every rule against a response and returns the extracted values.
+// Values that could not be extracted are left out and reported in the
+// returned error.
+func (rules Rules) Apply(header http.Header, body []byte) (map[string]any, error) {
+	values := make(map[string]any, len(rules))
+
+	var doc any
+	var docErr error
+	decodedOnce := false
+	decoded := func() (any, error) {
+		if !decodedOnce {
+			decodedOnce = true
+			doc, docErr = decodeJSON(body)
+		}
+
+		return doc, docErr
+	}
+
+	var errs []error
+	for _, name := range rules.names() {
+		compiled, err := rules[name].compile()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		value, err := compiled.apply(header, body, decoded)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		values[name] = value
+	}
+
+	return values, errors.Join(errs...)
+}
+
+// decodeJSON decodes a response body, keeping numbers as json.Number so an
+// extracted id such as 1234567 is used as written rather than as 1.234567e+06.
+func decodeJSON(body []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("response body is not valid json: %w", err)
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("response body is not valid json: unexpected data after the top level value")
+	}
+
+	return doc, nil
+}
+
+func (rules Rules) names() []string {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for k := range object {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,150 @@
+package extract_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/extract"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/templating"
+)
+
+const orderBody = `{
+	"id": "order-1",
+	"total": 42,
+	"data": {
+		"items": [
+			{"sku": "a", "qty": 1},
+			{"sku": "b", "qty": 2}
+		],
+		"meta data": {"source": "api"}
+	}
+}`
+
+func TestRulesApply(t *testing.T) {
+	header := http.Header{}
+	header.Set("Location", "/orders/order-1")
+
+	group := 0
+
+	tests := []struct {
+		name     string
+		rule     *extract.Rule
+		expected any
+	}{
+		{name: "JSONPathField", rule: &extract.Rule{JSONPath: "$.id"}, expected: "order-1"},
+		{name: "JSONPathNumber", rule: &extract.Rule{JSONPath: "$.total"}, expected: json.Number("42")},
+		{name: "JSONPathIndex", rule: &extract.Rule{JSONPath: "$.data.items[1].sku"}, expected: "b"},
+		{name: "JSONPathNegativeIndex", rule: &extract.Rule{JSONPath: "$.data.items[-1].qty"}, expected: json.Number("2")},
+		{name: "JSONPathQuoted", rule: &extract.Rule{JSONPath: "$.data['meta data'].source"}, expected: "api"},
+		{name: "JSONPathWildcard", rule: &extract.Rule{JSONPath: "$.data.items[*].sku"}, expected: []any{"a", "b"}},
+		{name: "JMESPathField", rule: &extract.Rule{JMESPath: "data.items[0].sku"}, expected: "a"},
+		{name: "JMESPathQuoted", rule: &extract.Rule{JMESPath: `data."meta data".source`}, expected: "api"},
+		{name: "JMESPathProjection", rule: &extract.Rule{JMESPath: "data.items[*].qty"}, expected: []any{json.Number("1"), json.Number("2")}},
+		{name: "Header", rule: &extract.Rule{Header: "location"}, expected: "/orders/order-1"},
+		{name: "RegexGroup", rule: &extract.Rule{Regex: `"id": "([^"]+)"`}, expected: "order-1"},
+		{name: "RegexWholeMatch", rule: &extract.Rule{Regex: `order-\d`, Group: &group}, expected: "order-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := extract.Rules{"value": tt.rule}
+
+			values, err := rules.Apply(header, []byte(orderBody))
+			if err != nil {
+				t.Fatalf("Apply() unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(values["value"], tt.expected) {
+				t.Errorf("Apply() value = %#v, want %#v", values["value"], tt.expected)
+			}
+		})
+	}
+}
+
+func TestRulesApplyPartialFailure(t *testing.T) {
+	rules := extract.Rules{
+		"id":      {JSONPath: "$.id"},
+		"missing": {JSONPath: "$.nope"},
+		"etag":    {Header: "ETag"},
+	}
+
+	values, err := rules.Apply(http.Header{}, []byte(orderBody))
+	if err == nil {
+		t.Errorf("Apply() expected error for missing values but got none")
+	}
+
+	if values["id"] != "order-1" {
+		t.Errorf("Apply() id = %v, want order-1", values["id"])
+	}
+
+	if _, ok := values["missing"]; ok {
+		t.Errorf("Apply() should not set values that failed to extract")
+	}
+}
+
+func TestRulesApplyLargeInteger(t *testing.T) {
+	rules := extract.Rules{"id": {JSONPath: "$.id"}, "ids": {JMESPath: "ids"}}
+
+	values, err := rules.Apply(http.Header{}, []byte(`{"id": 1234567, "ids": [9007199254740993]}`))
+	if err != nil {
+		t.Fatalf("Apply() unexpected error: %v", err)
+	}
+
+	url, err := templating.Render("/orders/{{.id}}", values)
+	if err != nil || url != "/orders/1234567" {
+		t.Errorf("rendered url = %q (%v), want /orders/1234567", url, err)
+	}
+
+	body, err := templating.RenderValue(map[string]any{"ids": "{{.ids}}"}, values)
+	if err != nil {
+		t.Fatalf("RenderValue() unexpected error: %v", err)
+	}
+	if data, _ := json.Marshal(body); string(data) != `{"ids":[9007199254740993]}` {
+		t.Errorf("rendered body = %s, want the ids as written", data)
+	}
+}
+
+func TestRulesApplyInvalidJSON(t *testing.T) {
+	rules := extract.Rules{"id": {JSONPath: "$.id"}}
+
+	if _, err := rules.Apply(http.Header{}, []byte("not json")); err == nil {
+		t.Errorf("Apply() expected error for non json body but got none")
+	}
+}
+
+func TestRulesValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       extract.Rules
+		expectError bool
+	}{
+		{name: "Valid", rules: extract.Rules{"id": {JSONPath: "$.id"}}, expectError: false},
+		{name: "NoSource", rules: extract.Rules{"id": {}}, expectError: true},
+		{name: "TwoSources", rules: extract.Rules{"id": {JSONPath: "$.id", Header: "Location"}}, expectError: true},
+		{name: "NilRule", rules: extract.Rules{"id": nil}, expectError: true},
+		{name: "JSONPathWithoutDollar", rules: extract.Rules{"id": {JSONPath: "id"}}, expectError: true},
+		{name: "RecursiveDescent", rules: extract.Rules{"id": {JSONPath: "$..id"}}, expectError: true},
+		{name: "UnterminatedBracket", rules: extract.Rules{"id": {JMESPath: "items[0"}}, expectError: true},
+		{name: "BadRegex", rules: extract.Rules{"id": {Regex: "("}}, expectError: true},
+		{name: "MissingGroup", rules: extract.Rules{"id": {Regex: "(a)", Group: intPtr(2)}}, expectError: true},
+		{name: "InvalidName", rules: extract.Rules{"order-id": {JSONPath: "$.id"}}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rules.Validate()
+			if tt.expectError && err == nil {
+				t.Errorf("Validate() expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Validate() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,201 @@
+package extract
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type segment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// Path is a compiled expression selecting a value from decoded JSON. It
+// supports the common subset of JSONPath and JMESPath: field access, quoted
+// fields, array indexes (negative indexes count from the end) and [*]
+// projections.
+type Path struct {
+	expr     string
+	segments []segment
+}
+
+// ParseJSONPath compiles a JSONPath expression such as $.data.items[0]['id'].
+func ParseJSONPath(expr string) (*Path, error) {
+	trimmed := strings.TrimSpace(expr)
+	if !strings.HasPrefix(trimmed, "$") {
+		return nil, fmt.Errorf("jsonPath %q must start with $", expr)
+	}
+
+	segments, err := parseSegments(trimmed[1:], true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jsonPath %q: %w", expr, err)
+	}
+
+	return &Path{expr: expr, segments: segments}, nil
+}
+
+// ParseJMESPath compiles a JMESPath expression such as data.items[0].id.
+func ParseJMESPath(expr string) (*Path, error) {
+	trimmed := strings.TrimSpace(expr)
+	if trimmed == "" {
+		return nil, fmt.Errorf("jmesPath can not be empty")
+	}
+
+	if trimmed == "@" {
+		return &Path{expr: expr}, nil
+	}
+
+	segments, err := parseSegments(trimmed, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jmesPath %q: %w", expr, err)
+	}
+
+	return &Path{expr: expr, segments: segments}, nil
+}
+
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Evaluate selects the value at the path, reporting false when nothing matches.
+func (p *Path) Evaluate(value any) (any, bool) {
+	return evaluate(value, p.segments)
+}
+
+func evaluate(value any, segments []segment) (any, bool) {
+	for i, seg := range segments {
+		switch {
+		case seg.wildcard:
+			var items []any
+			switch v := value.(type) {
+			case []any:
+				items = v
+			case map[string]any:
+				for _, k := range sortedKeys(v) {
+					items = append(items, v[k])
+				}
+			default:
+				return nil, false
+			}
+
+			results := make([]any, 0, len(items))
+			for _, item := range items {
+				if result, ok := evaluate(item, segments[i+1:]); ok {
+					results = append(results, result)
+				}
+			}
+
+			return results, true
+		case seg.isIndex:
+			list, ok := value.([]any)
+			if !ok {
+				return nil, false
+			}
+
+			index := seg.index
+			if index < 0 {
+				index += len(list)
+			}
+			if index < 0 || index >= len(list) {
+				return nil, false
+			}
+
+			value = list[index]
+		default:
+			object, ok := value.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+
+			value, ok = object[seg.key]
+			if !ok {
+				return nil, false
+			}
+		}
+	}
+
+	return value, true
+}
+
+func parseSegments(expr string, jsonPath bool) ([]segment, error) {
+	var segments []segment
+
+	i := 0
+	expectField := !jsonPath
+	for i < len(expr) {
+		switch {
+		case expr[i] == '.':
+			if expectField {
+				return nil, fmt.Errorf("unexpected '.' at offset %d", i)
+			}
+			i++
+			expectField = true
+		case expr[i] == '[':
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated '[' at offset %d", i)
+			}
+
+			seg, err := parseBracket(expr[i+1 : i+end])
+			if err != nil {
+				return nil, err
+			}
+
+			segments = append(segments, seg)
+			i += end + 1
+			expectField = false
+		case expectField:
+			if expr[i] == '*' {
+				segments = append(segments, segment{wildcard: true})
+				i++
+			} else if expr[i] == '"' {
+				end := strings.IndexByte(expr[i+1:], '"')
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated quoted field at offset %d", i)
+				}
+
+				segments = append(segments, segment{key: expr[i+1 : i+1+end]})
+				i += end + 2
+			} else {
+				start := i
+				for i < len(expr) && expr[i] != '.' && expr[i] != '[' {
+					i++
+				}
+
+				segments = append(segments, segment{key: expr[start:i]})
+			}
+
+			expectField = false
+		default:
+			return nil, fmt.Errorf("unexpected %q at offset %d", expr[i], i)
+		}
+	}
+
+	if expectField && len(expr) > 0 {
+		return nil, fmt.Errorf("expression can not end with '.'")
+	}
+
+	return segments, nil
+}
+
+func parseBracket(inner string) (segment, error) {
+	inner = strings.TrimSpace(inner)
+
+	if inner == "*" {
+		return segment{wildcard: true}, nil
+	}
+
+	if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+		return segment{key: inner[1 : len(inner)-1]}, nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return segment{}, fmt.Errorf("unsupported selector [%s]", inner)
+	}
+
+	return segment{index: index, isIndex: true}, nil
+}
@@ -0,0 +1,291 @@
+package feeder
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/assert"
+)
+
+// Modes a Feeder can hand out rows in.
+const (
+	ModeSequential = "sequential"
+	ModeRandom     = "random"
+	ModeUnique     = "unique"
+)
+
+// Formats a Feeder can read rows from.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// ErrExhausted is returned by Next once a unique Feeder has handed out every
+// row.
+var ErrExhausted = errors.New("every row has been used")
+
+// Config describes a file of rows. Format defaults to the file extension and
+// Mode to sequential, where rows are handed out in order and start again from
+// the first row once every row has been used. Random picks any row each time
+// and unique hands out every row exactly once.
+type Config struct {
+	File   string `json:"file"`
+	Format string `json:"format,omitempty"`
+	Mode   string `json:"mode,omitempty"`
+}
+
+// FormatOrDefault returns the configured format, falling back to the one
+// matching the file extension.
+func (c *Config) FormatOrDefault() string {
+	if c.Format != "" {
+		return strings.ToLower(c.Format)
+	}
+
+	switch strings.ToLower(filepath.Ext(c.File)) {
+	case ".csv":
+		return FormatCSV
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	default:
+		return ""
+	}
+}
+
+func (c *Config) ModeOrDefault() string {
+	if c.Mode == "" {
+		return ModeSequential
+	}
+
+	return strings.ToLower(c.Mode)
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.File == "" {
+		errs = append(errs, fmt.Errorf("file can not be empty"))
+	}
+
+	switch c.FormatOrDefault() {
+	case FormatCSV, FormatJSONL:
+	default:
+		errs = append(errs, fmt.Errorf("unsupported format %q for %q, expected %s or %s", c.Format, c.File, FormatCSV, FormatJSONL))
+	}
+
+	switch c.ModeOrDefault() {
+	case ModeSequential, ModeRandom, ModeUnique:
+	default:
+		errs = append(errs, fmt.Errorf("unsupported mode %q, expected %s, %s or %s", c.Mode, ModeSequential, ModeRandom, ModeUnique))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Sources maps variable names to the file of rows fed under that name.
+type Sources map[string]*Config
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (s Sources) Validate() error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(s)) {
+		if !namePattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("%q is not a valid variable name", name))
+			continue
+		}
+
+		if s[name] == nil {
+			errs = append(errs, fmt.Errorf("%s: data can not be empty", name))
+			continue
+		}
+
+		if err := s[name].Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ResolvePaths makes the relative file of every source relative to dir rather
+// than the working directory, for a config read from a file in dir.
+func (s Sources) ResolvePaths(dir string) {
+	for _, c := range s {
+		if c != nil && c.File != "" && !filepath.IsAbs(c.File) {
+			c.File = filepath.Join(dir, c.File)
+		}
+	}
+}
+
+// Load reads the rows of every source.
+func (s Sources) Load() (map[string]*Feeder, error) {
+	feeders := make(map[string]*Feeder, len(s))
+	for _, name := range slices.Sorted(maps.Keys(s)) {
+		f, err := Load(s[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		feeders[name] = f
+	}
+
+	return feeders, nil
+}
+
+// Feeder hands out the rows of a data file to clients. It is safe for
+// concurrent use.
+type Feeder struct {
+	mode  string
+	rows  []map[string]any
+	mutex sync.Mutex
+	next  int
+}
+
+func NewFeeder(mode string, rows []map[string]any) *Feeder {
+	assert.Assert(mode == ModeSequential || mode == ModeRandom || mode == ModeUnique, "Feeder mode must be sequential, random or unique", "mode", mode)
+	assert.Assert(len(rows) > 0, "Feeder must have at least one row")
+
+	return &Feeder{mode: mode, rows: rows}
+}
+
+// Load reads every row of the file described by config.
+func Load(config *Config) (*Feeder, error) {
+	if config == nil {
+		return nil, fmt.Errorf("data can not be empty")
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(config.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data file: %w", err)
+	}
+	defer file.Close()
+
+	var rows []map[string]any
+	switch config.FormatOrDefault() {
+	case FormatCSV:
+		rows, err = readCSV(file)
+	case FormatJSONL:
+		rows, err = readJSONL(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file %s: %w", config.File, err)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("data file %s has no rows", config.File)
+	}
+
+	return NewFeeder(config.ModeOrDefault(), rows), nil
+}
+
+// Len returns the number of rows.
+func (f *Feeder) Len() int {
+	return len(f.rows)
+}
+
+// Next returns the next row to hand to a client. A unique Feeder returns
+// ErrExhausted once every row has been used.
+func (f *Feeder) Next() (map[string]any, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	switch f.mode {
+	case ModeRandom:
+		return f.rows[rand.IntN(len(f.rows))], nil
+	case ModeUnique:
+		if f.next >= len(f.rows) {
+			return nil, ErrExhausted
+		}
+	}
+
+	row := f.rows[f.next%len(f.rows)]
+	f.next++
+
+	return row, nil
+}
+
+// Fresh returns a Feeder over the same rows that starts from the first row
+// again.
+func (f *Feeder) Fresh() *Feeder {
+	return NewFeeder(f.mode, f.rows)
+}
+
+// readCSV reads a CSV file whose first record names the columns.
+func readCSV(r io.Reader) ([]map[string]any, error) {
+	reader := csv.NewReader(r)
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	rows := make([]map[string]any, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]any, len(header))
+		for i, column := range header {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// readJSONL reads a file with a JSON object on every non-empty line.
+func readJSONL(r io.Reader) ([]map[string]any, error) {
+	var rows []map[string]any
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		row, err := decodeRow(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d is not a json object: %w", line, err)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, scanner.Err()
+}
+
+// decodeRow decodes a JSONL row, keeping numbers as json.Number so a column
+// such as an id of 1234567 renders as written rather than as 1.234567e+06.
+func decodeRow(text string) (map[string]any, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+
+	var row map[string]any
+	if err := decoder.Decode(&row); err != nil {
+		return nil, err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the object")
+	}
+
+	return row, nil
+}
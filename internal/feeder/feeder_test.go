@@ -0,0 +1,200 @@
+package feeder_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/feeder"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		format  string
+		rows    int
+		first   map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "CSV",
+			file:    "users.csv",
+			content: "email,password\na@example.com,one\nb@example.com,two\n",
+			rows:    2,
+			first:   map[string]any{"email": "a@example.com", "password": "one"},
+		},
+		{
+			name:    "JSONL",
+			file:    "users.jsonl",
+			content: "{\"email\": \"a@example.com\", \"age\": 30}\n\n{\"email\": \"b@example.com\", \"age\": 40}\n",
+			rows:    2,
+			first:   map[string]any{"email": "a@example.com", "age": json.Number("30")},
+		},
+		{
+			name:    "JSONLLargeInteger",
+			file:    "orders.jsonl",
+			content: "{\"id\": 1234567, \"price\": 9.99}\n",
+			rows:    1,
+			first:   map[string]any{"id": json.Number("1234567"), "price": json.Number("9.99")},
+		},
+		{
+			name:    "JSONLTrailingData",
+			file:    "users.jsonl",
+			content: "{\"email\": \"a@example.com\"} {}\n",
+			wantErr: true,
+		},
+		{
+			name:    "ExplicitFormat",
+			file:    "users.txt",
+			content: "email\na@example.com\n",
+			format:  "csv",
+			rows:    1,
+			first:   map[string]any{"email": "a@example.com"},
+		},
+		{
+			name:    "UnknownExtension",
+			file:    "users.txt",
+			content: "email\na@example.com\n",
+			wantErr: true,
+		},
+		{
+			name:    "HeaderOnly",
+			file:    "users.csv",
+			content: "email\n",
+			wantErr: true,
+		},
+		{
+			name:    "RaggedCSV",
+			file:    "users.csv",
+			content: "email,password\na@example.com\n",
+			wantErr: true,
+		},
+		{
+			name:    "JSONLNotAnObject",
+			file:    "users.jsonl",
+			content: "[1, 2]\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, tt.file, tt.content)
+
+			f, err := feeder.Load(&feeder.Config{File: path, Format: tt.format})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Load() loaded %d rows, want an error", f.Len())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error: %v", err)
+			}
+
+			if f.Len() != tt.rows {
+				t.Errorf("Len() = %d, want %d", f.Len(), tt.rows)
+			}
+
+			row, err := f.Next()
+			if err != nil {
+				t.Fatalf("Next() error: %v", err)
+			}
+			for k, v := range tt.first {
+				if row[k] != v {
+					t.Errorf("first row %s = %v, want %v", k, row[k], v)
+				}
+			}
+		})
+	}
+
+	if _, err := feeder.Load(&feeder.Config{File: filepath.Join(t.TempDir(), "missing.csv")}); err == nil {
+		t.Errorf("Load() of a missing file should fail")
+	}
+}
+
+func TestFeederModes(t *testing.T) {
+	rows := []map[string]any{{"id": "a"}, {"id": "b"}, {"id": "c"}}
+
+	sequential := feeder.NewFeeder(feeder.ModeSequential, rows)
+	var got []any
+	for range 5 {
+		row, err := sequential.Next()
+		if err != nil {
+			t.Fatalf("sequential Next() error: %v", err)
+		}
+		got = append(got, row["id"])
+	}
+	if want := []any{"a", "b", "c", "a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("sequential rows = %v, want %v", got, want)
+	}
+
+	fresh := sequential.Fresh()
+	if row, _ := fresh.Next(); row["id"] != "a" {
+		t.Errorf("Fresh() started at %v, want a", row["id"])
+	}
+
+	unique := feeder.NewFeeder(feeder.ModeUnique, rows)
+	seen := map[any]bool{}
+	for range 3 {
+		row, err := unique.Next()
+		if err != nil {
+			t.Fatalf("unique Next() error: %v", err)
+		}
+		seen[row["id"]] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("unique handed out %d distinct rows, want 3", len(seen))
+	}
+	if _, err := unique.Next(); !errors.Is(err, feeder.ErrExhausted) {
+		t.Errorf("unique Next() after every row = %v, want ErrExhausted", err)
+	}
+
+	random := feeder.NewFeeder(feeder.ModeRandom, rows)
+	for range 10 {
+		if _, err := random.Next(); err != nil {
+			t.Fatalf("random Next() error: %v", err)
+		}
+	}
+}
+
+func TestSourcesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources feeder.Sources
+		wantErr bool
+	}{
+		{name: "Empty", sources: nil},
+		{name: "Valid", sources: feeder.Sources{"users": {File: "users.csv", Mode: "unique"}}},
+		{name: "InvalidName", sources: feeder.Sources{"user-list": {File: "users.csv"}}, wantErr: true},
+		{name: "NilSource", sources: feeder.Sources{"users": nil}, wantErr: true},
+		{name: "MissingFile", sources: feeder.Sources{"users": {Format: "csv"}}, wantErr: true},
+		{name: "InvalidMode", sources: feeder.Sources{"users": {File: "users.csv", Mode: "shuffled"}}, wantErr: true},
+		{name: "InvalidFormat", sources: feeder.Sources{"users": {File: "users.xml"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sources.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
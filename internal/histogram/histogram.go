@@ -0,0 +1,142 @@
+package histogram
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Each power of two range of values is split into subBucketHalfCount linear
+// sub buckets, so a recorded value is off by less than 1/subBucketHalfCount
+// (under 1%) no matter how large it is, the same trade off an HDR histogram
+// makes.
+const (
+	subBucketHalfCountMagnitude = 7
+	subBucketHalfCount          = 1 << subBucketHalfCountMagnitude
+	subBucketCount              = 2 * subBucketHalfCount
+	subBucketMask               = subBucketCount - 1
+)
+
+// Histogram records durations in log-linear buckets, so percentiles of any
+// number of samples can be read back in constant memory. Min, max and mean
+// are tracked exactly. A Histogram is not safe for concurrent use.
+type Histogram struct {
+	counts []int64
+	count  int64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+func New() *Histogram {
+	return &Histogram{}
+}
+
+// Record adds d to the histogram. Negative durations are recorded as 0.
+func (h *Histogram) Record(d time.Duration) {
+	d = max(d, 0)
+
+	index := countsIndex(d)
+	if index >= len(h.counts) {
+		counts := make([]int64, index+1)
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+
+	h.counts[index]++
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.sum += d
+}
+
+// Merge adds every value recorded in other to h.
+func (h *Histogram) Merge(other *Histogram) {
+	if other == nil || other.count == 0 {
+		return
+	}
+
+	if len(other.counts) > len(h.counts) {
+		counts := make([]int64, len(other.counts))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	h.max = max(h.max, other.max)
+	h.count += other.count
+	h.sum += other.sum
+}
+
+func (h *Histogram) Count() int64 {
+	return h.count
+}
+
+func (h *Histogram) Min() time.Duration {
+	return h.min
+}
+
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	return h.sum / time.Duration(h.count)
+}
+
+// Percentile returns the value below which p percent of the recorded values
+// fall, accurate to the bucket the value was recorded in. It returns 0 when
+// nothing was recorded.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	p = min(max(p, 0), 100)
+	rank := max(int64(math.Ceil(p/100*float64(h.count))), 1)
+
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			return min(max(highestEquivalentValue(i), h.min), h.max)
+		}
+	}
+
+	return h.max
+}
+
+func countsIndex(d time.Duration) int {
+	v := uint64(d)
+	bucket := bits.Len64(v|subBucketMask) - (subBucketHalfCountMagnitude + 1)
+	subBucket := int(v >> bucket)
+
+	return (bucket+1)<<subBucketHalfCountMagnitude + subBucket - subBucketHalfCount
+}
+
+// highestEquivalentValue returns the largest value that is recorded at index.
+func highestEquivalentValue(index int) time.Duration {
+	bucket := index>>subBucketHalfCountMagnitude - 1
+	subBucket := index&(subBucketHalfCount-1) + subBucketHalfCount
+	if bucket < 0 {
+		subBucket -= subBucketHalfCount
+		bucket = 0
+	}
+
+	lowest := uint64(subBucket) << bucket
+	return time.Duration(lowest + 1<<bucket - 1)
+}
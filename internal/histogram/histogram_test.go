@@ -0,0 +1,87 @@
+package histogram_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/histogram"
+)
+
+func TestHistogramPercentile(t *testing.T) {
+	h := histogram.New()
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	tests := []struct {
+		name       string
+		percentile float64
+		expected   time.Duration
+	}{
+		{name: "Min", percentile: 0, expected: time.Millisecond},
+		{name: "P50", percentile: 50, expected: 500 * time.Millisecond},
+		{name: "P90", percentile: 90, expected: 900 * time.Millisecond},
+		{name: "P99", percentile: 99, expected: 990 * time.Millisecond},
+		{name: "Max", percentile: 100, expected: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := h.Percentile(tt.percentile)
+
+			// Values share a bucket with their neighbours, so they are only
+			// accurate to within 1%.
+			if diff := math.Abs(float64(got - tt.expected)); diff > float64(tt.expected)/100 {
+				t.Errorf("Percentile(%v) = %v, want %v within 1%%", tt.percentile, got, tt.expected)
+			}
+		})
+	}
+
+	if h.Count() != 1000 || h.Min() != time.Millisecond || h.Max() != time.Second {
+		t.Errorf("count, min, max = %d, %v, %v", h.Count(), h.Min(), h.Max())
+	}
+	if h.Mean() != 500500*time.Microsecond {
+		t.Errorf("Mean() = %v, want 500.5ms", h.Mean())
+	}
+}
+
+func TestHistogramSmallValuesAreExact(t *testing.T) {
+	h := histogram.New()
+	for i := range 200 {
+		h.Record(time.Duration(i))
+	}
+
+	for _, p := range []float64{1, 25, 50, 75, 100} {
+		expected := time.Duration(math.Ceil(p/100*200)) - 1
+		if got := h.Percentile(p); got != expected {
+			t.Errorf("Percentile(%v) = %v, want %v", p, got, expected)
+		}
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	a := histogram.New()
+	b := histogram.New()
+	for i := 1; i <= 10; i++ {
+		a.Record(time.Duration(i) * time.Millisecond)
+		b.Record(time.Duration(i) * time.Second)
+	}
+
+	a.Merge(b)
+	a.Merge(nil)
+
+	if a.Count() != 20 || a.Min() != time.Millisecond || a.Max() != 10*time.Second {
+		t.Errorf("count, min, max = %d, %v, %v", a.Count(), a.Min(), a.Max())
+	}
+	if got := a.Percentile(50); got < 10*time.Millisecond || got > 10100*time.Microsecond {
+		t.Errorf("Percentile(50) = %v, want 10ms within 1%%", got)
+	}
+}
+
+func TestHistogramEmpty(t *testing.T) {
+	h := histogram.New()
+	if h.Count() != 0 || h.Mean() != 0 || h.Percentile(99) != 0 || h.Max() != 0 {
+		t.Errorf("empty histogram = %d, %v, %v, %v", h.Count(), h.Mean(), h.Percentile(99), h.Max())
+	}
+}
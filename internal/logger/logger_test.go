@@ -69,7 +69,7 @@ func TestIsHandledKeyConcurrency(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for j := range numIterations {
+			for range numIterations {
 				result := isHandledKey(ProcessKey)
 				results <- result
 			}
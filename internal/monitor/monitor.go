@@ -4,20 +4,26 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
 	"reflect"
 	"time"
 
 	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
 	"github.com/Easy-Infra-Ltd/easy-test/internal/assert"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/events"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/histogram"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/templating"
 	"github.com/Easy-Infra-Ltd/easy-test/internal/threadpool"
 )
 
 type MonitorTargetConfig struct {
-	Client           *api.ClientConfig `json:"client"`
-	Freq             time.Duration     `json:"freq"`
-	Retries          int               `json:"retries"`
-	ExpectedResponse map[string]any    `json:"expectedResponse"`
+	Client           *api.ClientConfig  `json:"client"`
+	Freq             duration.OrSeconds `json:"freq"`
+	Retries          int                `json:"retries"`
+	ExpectedResponse map[string]any     `json:"expectedResponse"`
 }
 
 type MonitorConfig struct {
@@ -25,11 +31,50 @@ type MonitorConfig struct {
 	MonitorTargets []*MonitorTargetConfig `json:"monitorTargets"`
 }
 
+// ResolvePaths makes the relative paths of every target's client relative to
+// dir, see api.ClientConfig.ResolvePaths.
+func (c *MonitorConfig) ResolvePaths(dir string) {
+	if c == nil {
+		return
+	}
+
+	for _, target := range c.MonitorTargets {
+		if target != nil {
+			target.Client.ResolvePaths(dir)
+		}
+	}
+}
+
+// Redacted returns a copy of the config with the secrets of every target's
+// client replaced, see api.ClientConfig.Redacted.
+func (c *MonitorConfig) Redacted() *MonitorConfig {
+	if c == nil {
+		return nil
+	}
+
+	redacted := *c
+	redacted.MonitorTargets = make([]*MonitorTargetConfig, len(c.MonitorTargets))
+	for i, target := range c.MonitorTargets {
+		if target == nil {
+			continue
+		}
+
+		redactedTarget := *target
+		redactedTarget.Client = target.Client.Redacted()
+		redacted.MonitorTargets[i] = &redactedTarget
+	}
+
+	return &redacted
+}
+
 func CreateMonitorTargetsFromConfig(monitorTargetConfig []*MonitorTargetConfig) []*MonitorTarget {
 	monitorTargets := make([]*MonitorTarget, 0, len(monitorTargetConfig))
 	for _, v := range monitorTargetConfig {
-		client := api.NewClient(api.NewClientParams(v.Client.Url, v.Client.ContentType, nil))
-		monitorTarget := NewMonitorTarget(client, v.ExpectedResponse, v.Freq*time.Second, v.Retries)
+		params, err := api.NewClientParamsFromConfig(v.Client, http.MethodGet)
+		assert.NoError(err, "Monitor target client config must be valid")
+
+		client := api.NewClient(params)
+		monitorTarget := NewMonitorTarget(client, v.ExpectedResponse, v.Freq.Std(), v.Retries)
 
 		monitorTargets = append(monitorTargets, monitorTarget)
 	}
@@ -56,68 +101,155 @@ func NewMonitorTarget(client *api.Client, expectedResponse map[string]any, freq
 	}
 }
 
+// TargetPlan describes how a MonitorTarget would poll, without sending any
+// requests. Duration is the longest the target polls for.
+type TargetPlan struct {
+	Request  *api.RequestPlan  `json:"request"`
+	Freq     duration.Duration `json:"freq"`
+	Retries  int               `json:"retries"`
+	Duration duration.Duration `json:"duration"`
+	Expected any               `json:"expectedResponse,omitempty"`
+}
+
+func (t *MonitorTarget) Plan(vars map[string]any) (*TargetPlan, error) {
+	request, err := t.client.Plan(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := templating.RenderValue(t.expectedResponse, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render expected response: %w", err)
+	}
+
+	return &TargetPlan{
+		Request:  request,
+		Freq:     duration.Duration(t.freq),
+		Retries:  t.retries,
+		Duration: duration.Duration(time.Duration(t.retries) * t.freq),
+		Expected: expected,
+	}, nil
+}
+
 type Monitor struct {
 	name    string
 	targets []*MonitorTarget
+	vars    map[string]any
+	workers int
+	session *api.Session
 	ctx     context.Context
 	cancel  context.CancelFunc
 	logger  *slog.Logger
 }
 
-func NewMonitor(name string, targets []*MonitorTarget) *Monitor {
+type MonitorOption func(*Monitor)
+
+// WithSession sends every poll as part of session, with its cookies and
+// connections, so a monitor can poll as the user that triggered it.
+func WithSession(session *api.Session) MonitorOption {
+	return func(m *Monitor) {
+		m.session = session
+	}
+}
+
+// NewMonitor creates a Monitor over targets. The target requests and expected
+// responses are rendered as templates against vars, which usually hold values
+// extracted from the request that triggered the monitor. Polling stops when
+// ctx is done and at most workers targets are polled at once.
+func NewMonitor(ctx context.Context, name string, targets []*MonitorTarget, vars map[string]any, workers int, options ...MonitorOption) *Monitor {
+	assert.NotNil(ctx, "Context can not be nil when creating a Monitor")
 	assert.Assert(len(targets) > 0, "Can not have 0 clients to monitor")
+	assert.Assert(workers > 0, "Monitor must have at least 1 worker")
 
 	logger := slog.Default().With("area", "Monitor "+name)
 
-	ctx, cancel := context.WithCancel(context.Background())
-	return &Monitor{
+	ctx, cancel := context.WithCancel(ctx)
+	m := &Monitor{
 		name:    name,
 		targets: targets,
+		vars:    vars,
+		workers: workers,
 		ctx:     ctx,
 		cancel:  cancel,
 		logger:  logger,
 	}
+
+	for _, option := range options {
+		option(m)
+	}
+
+	return m
 }
 
-func (m *Monitor) Start() {
+// Start polls every target until it returns the expected response, runs out
+// of retries or the monitor context is done. It returns the result of each
+// target in the same order as the targets.
+func (m *Monitor) Start() []*Result {
 	assert.Assert(len(m.targets) > 0, "When calling Start on Monitor must have more than 0 clients to monitor")
+	defer m.cancel()
 
-	tp := threadpool.NewThreadPool(1, 10, 5*time.Second)
+	tp := threadpool.NewThreadPool(1, min(m.workers, threadpool.MAX_WORKERS), 5*time.Second)
 	tp.Run()
+	defer tp.Stop()
 
 	m.logger.Info("Adding Monitor Tasks to thread pool")
+	tasks := make([]*MonitorTask, 0, len(m.targets))
 	for _, v := range m.targets {
 		assert.Assert(v.freq > 0, "When calling Start on Monitor freq must be greater than 0")
 
-		task := NewMonitorTask(m.ctx, m.name, v, v.freq, v.retries)
+		task := NewMonitorTask(m.ctx, m.name, v, m.vars, v.freq, v.retries)
+		task.session = m.session
+		tasks = append(tasks, task)
 		tp.Add(task)
 	}
 
 	tp.Wait()
+
+	results := make([]*Result, 0, len(tasks))
+	for _, task := range tasks {
+		results = append(results, task.Result())
+	}
+
+	return results
 }
 
 type MonitorTask struct {
 	name    string
 	target  *MonitorTarget
+	vars    map[string]any
 	freq    time.Duration
 	retries int
+	session *api.Session
+	result  *Result
 	ctx     context.Context
 	logger  *slog.Logger
 }
 
-func NewMonitorTask(ctx context.Context, name string, target *MonitorTarget, freq time.Duration, retries int) *MonitorTask {
+func NewMonitorTask(ctx context.Context, name string, target *MonitorTarget, vars map[string]any, freq time.Duration, retries int) *MonitorTask {
 	assert.NotNil(target, "Target can not be nil when creating a MonitorTask")
 	assert.Assert(freq > 0, "Freq must be greater than 0 when creating a MonitorTask")
 
 	logger := slog.Default().With("area", "Monitor Task "+name)
 
+	url, err := templating.Render(target.client.Url(), vars)
+	if err != nil {
+		url = target.client.Url()
+	}
+
 	return &MonitorTask{
 		name:    name,
 		target:  target,
+		vars:    vars,
 		freq:    freq,
 		retries: retries,
-		ctx:     ctx,
-		logger:  logger,
+		result: &Result{
+			Name:    name,
+			Method:  target.client.Method(),
+			Url:     url,
+			Latency: histogram.New(),
+		},
+		ctx:    ctx,
+		logger: logger,
 	}
 }
 
@@ -125,7 +257,44 @@ func (m MonitorTask) GetName() string {
 	return m.name
 }
 
+// Result returns the outcome of the task, which is only complete once Run
+// has returned.
+func (m *MonitorTask) Result() *Result {
+	return m.result
+}
+
+// verdictEvents maps the verdict of a monitored target to the event emitted
+// when it finishes.
+var verdictEvents = map[string]string{
+	VerdictSatisfied: events.MonitorSatisfied,
+	VerdictExhausted: events.MonitorExhausted,
+	VerdictCancelled: events.MonitorCancelled,
+	VerdictError:     events.MonitorError,
+}
+
 func (m *MonitorTask) Run() {
+	start := time.Now()
+	m.result.Verdict = VerdictExhausted
+	emitter := events.FromContext(m.ctx)
+	defer func() {
+		m.result.Duration = duration.Duration(time.Since(start))
+		emitter.Emit(verdictEvents[m.result.Verdict], map[string]any{
+			"monitor":  m.name,
+			"url":      m.result.Url,
+			"polls":    m.result.Polls,
+			"duration": m.result.Duration.String(),
+			"error":    m.result.Error,
+		})
+	}()
+
+	expected, err := renderExpected(m.target.expectedResponse, m.vars)
+	if err != nil {
+		m.logger.Error(fmt.Sprintf("Unable to render expected response: %s", err.Error()))
+		m.result.Verdict = VerdictError
+		m.result.Error = err.Error()
+		return
+	}
+
 	for i := 0; i < m.retries; i++ {
 		assert.NotNil(m.target, "Target should not be nil when trying to run Monitor Task")
 		assert.NotNil(m.target.client, "Client should not be nil on the target when trying to run the Monitor Task")
@@ -133,27 +302,102 @@ func (m *MonitorTask) Run() {
 		select {
 		case <-m.ctx.Done():
 			m.logger.Info("Monitor finished, exiting")
+			m.result.Verdict = VerdictCancelled
 			return
 		default:
-			resp, _ := m.target.client.Get()
-			m.logger.Info(fmt.Sprintf("Response %+v", resp))
+			m.result.Polls++
+			poll := m.poll(expected)
+			m.result.History = append(m.result.History, poll)
+			m.result.Error = poll.Error
+			emitter.Emit(events.MonitorPoll, map[string]any{
+				"monitor":    m.name,
+				"url":        m.result.Url,
+				"poll":       m.result.Polls,
+				"statusCode": poll.StatusCode,
+				"latency":    poll.Latency.String(),
+				"matched":    poll.Matched,
+				"error":      poll.Error,
+			})
 
-			if resp != nil && resp.Body != nil {
-				m.logger.Info(fmt.Sprintf("Response Body %+v", resp.Body))
+			if poll.Matched {
+				m.logger.Info("Successfully found response")
+				m.result.Verdict = VerdictSatisfied
+				m.result.TimeToSuccess = duration.Duration(time.Since(start))
+				m.result.Response = ""
+				return
+			}
 
-				var v map[string]any
-				jsonErr := json.NewDecoder(resp.Body).Decode(&v)
-				assert.NoError(jsonErr, "Can not error when decoding json from monitored GET request")
+			select {
+			case <-m.ctx.Done():
+				m.logger.Info("Monitor finished, exiting")
+				m.result.Verdict = VerdictCancelled
+				return
+			case <-time.After(m.freq):
+			}
+		}
+	}
+}
 
-				resp.Body.Close()
+// renderExpected renders the expected response against vars, round tripping
+// it through JSON so values taken from vars, such as an extracted json.Number,
+// compare the same way as the decoded response.
+func renderExpected(expected map[string]any, vars map[string]any) (any, error) {
+	rendered, err := templating.RenderValue(expected, vars)
+	if err != nil {
+		return nil, err
+	}
 
-				if reflect.DeepEqual(m.target.expectedResponse, v) {
-					m.logger.Info("Successfully found response")
-					return
-				}
-			}
+	data, err := json.Marshal(rendered)
+	if err != nil {
+		return nil, err
+	}
 
-			time.Sleep(m.freq)
-		}
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+
+	return normalized, nil
+}
+
+// poll sends a single request to the target and compares the response with
+// expected.
+func (m *MonitorTask) poll(expected any) *Poll {
+	poll := &Poll{StartedAt: time.Now()}
+
+	req, err := m.target.client.NewRequest(m.ctx, m.vars)
+	if err != nil {
+		m.logger.Error(err.Error())
+		poll.Error = err.Error()
+		return poll
 	}
+
+	resp, err := m.target.client.SendWith(m.session, req)
+	if err != nil {
+		m.logger.Error(err.Error())
+		poll.Error = err.Error()
+		return poll
+	}
+	m.logger.Info(fmt.Sprintf("Response %+v", resp))
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	poll.Latency = duration.Duration(time.Since(poll.StartedAt))
+	poll.StatusCode = resp.StatusCode
+	m.result.Latency.Record(poll.Latency.Std())
+	m.result.Response = string(body)
+
+	var v map[string]any
+	if err == nil {
+		err = json.Unmarshal(body, &v)
+	}
+
+	if err != nil {
+		m.logger.Warn(fmt.Sprintf("Unable to decode json from monitored %s request: %s", m.target.client.Method(), err.Error()))
+		poll.Error = err.Error()
+		return poll
+	}
+
+	poll.Matched = reflect.DeepEqual(expected, v)
+	return poll
 }
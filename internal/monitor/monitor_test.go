@@ -1,9 +1,11 @@
 package monitor_test
 
 import (
+	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -14,9 +16,15 @@ import (
 
 type MonitorTestParams struct {
 	name     string
+	method   string
 	cliCount int
 	freq     time.Duration
 	retries  int
+	vars     map[string]any
+	path     string
+	expected map[string]any
+	verdict  string
+	polls    int
 }
 
 func handleGetTest(res http.ResponseWriter, req *http.Request) {
@@ -33,41 +41,144 @@ func handleGetTest(res http.ResponseWriter, req *http.Request) {
 	writer.Encode(response)
 }
 
+func handleGetOrder(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+
+	response := map[string]any{
+		"id":     req.PathValue("id"),
+		"status": "shipped " + req.PathValue("id"),
+		"total":  42,
+	}
+	json.NewEncoder(res).Encode(response)
+}
+
 func TestMonitor(t *testing.T) {
 	logger := logger.CreateLoggerFromEnv(nil, "lightRed")
 	logger = logger.With("area", "Monitor Test").With("process", "test")
 	slog.SetDefault(logger)
 
-	s := api.NewServer("TestServer", ":3333")
-	s.AddRoute("GET /test", handleGetTest)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /test", handleGetTest)
+	mux.HandleFunc("PUT /test", handleGetTest)
+	mux.HandleFunc("GET /orders/{id}", handleGetOrder)
 
-	go s.Start()
+	s := httptest.NewServer(mux)
+	defer s.Close()
 
 	t.Parallel()
 
 	tests := []MonitorTestParams{
 		{
 			name:     "3 Clients every 3 seconds 3 retries",
+			method:   http.MethodGet,
 			cliCount: 3,
-			freq:     3,
+			freq:     3 * time.Second,
 			retries:  3,
+			path:     "/test",
+			expected: map[string]any{
+				"id":   "test",
+				"name": "A Test Response",
+			},
+		},
+		{
+			name:     "1 Client polling with PUT",
+			method:   http.MethodPut,
+			cliCount: 1,
+			freq:     time.Second,
+			retries:  1,
+			path:     "/test",
+			expected: map[string]any{
+				"id":   "test",
+				"name": "A Test Response",
+			},
+		},
+		{
+			name:     "Templated url and expected response from extracted vars",
+			method:   http.MethodGet,
+			cliCount: 1,
+			freq:     time.Second,
+			retries:  1,
+			vars:     map[string]any{"id": "abc-123", "total": json.Number("42")},
+			path:     "/orders/{{.id}}",
+			expected: map[string]any{
+				"id":     "{{.id}}",
+				"status": "shipped {{.id}}",
+				"total":  "{{.total}}",
+			},
+		},
+		{
+			name:     "Unexpected response exhausts retries",
+			method:   http.MethodGet,
+			cliCount: 1,
+			freq:     10 * time.Millisecond,
+			retries:  2,
+			path:     "/test",
+			expected: map[string]any{"id": "other"},
+			verdict:  monitor.VerdictExhausted,
+			polls:    2,
 		},
 	}
 	for _, v := range tests {
 		t.Run(v.name, func(t *testing.T) {
 			targets := make([]*monitor.MonitorTarget, 0, v.cliCount)
 			for i := 0; i < v.cliCount; i++ {
-				cli := api.NewClient(api.NewClientParams("http://localhost:3333/test", "application/json", nil))
+				cli := api.NewClient(api.NewClientParams(v.method, s.URL+v.path, "application/json", nil))
 
-				expectedResponse := map[string]any{
-					"id":   "test",
-					"name": "A Test Response",
-				}
-				targets = append(targets, monitor.NewMonitorTarget(cli, expectedResponse))
+				targets = append(targets, monitor.NewMonitorTarget(cli, v.expected, v.freq, v.retries))
+			}
+
+			verdict, polls := v.verdict, v.polls
+			if verdict == "" {
+				verdict, polls = monitor.VerdictSatisfied, 1
 			}
 
-			m := monitor.NewMonitor("Monitor Site", targets, v.freq, v.retries)
-			m.Start()
+			m := monitor.NewMonitor(t.Context(), "Monitor Site", targets, v.vars, 10)
+			results := m.Start()
+
+			if len(results) != v.cliCount {
+				t.Fatalf("Start() returned %d results, want %d", len(results), v.cliCount)
+			}
+			for _, result := range results {
+				if result.Verdict != verdict || result.Polls != polls {
+					t.Errorf("result = %+v, want verdict %s after %d polls", result, verdict, polls)
+				}
+				if result.Satisfied() != (result.TimeToSuccess > 0) {
+					t.Errorf("result = %+v, time to success should only be set when satisfied", result)
+				}
+				if len(result.History) != polls || result.History[polls-1].Matched != result.Satisfied() {
+					t.Errorf("history = %+v, want %d polls with only a satisfied result matching last", result.History, polls)
+				}
+			}
 		})
 	}
 }
+
+func TestMonitorStopsWhenContextDone(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /pending", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(res).Encode(map[string]any{"status": "pending"})
+	})
+
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	cli := api.NewClient(api.NewClientParams(http.MethodGet, s.URL+"/pending", "application/json", nil))
+	targets := []*monitor.MonitorTarget{
+		monitor.NewMonitorTarget(cli, map[string]any{"status": "done"}, time.Second, 100),
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	results := monitor.NewMonitor(ctx, "Pending", targets, nil, 1).Start()
+
+	if results[0].Verdict != monitor.VerdictCancelled {
+		t.Errorf("verdict = %s, want %s", results[0].Verdict, monitor.VerdictCancelled)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("monitor kept polling for %s after its context was done", elapsed)
+	}
+}
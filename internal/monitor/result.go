@@ -0,0 +1,49 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/histogram"
+)
+
+// Verdicts a monitored target can finish with.
+const (
+	VerdictSatisfied = "satisfied"
+	VerdictExhausted = "exhausted"
+	VerdictCancelled = "cancelled"
+	VerdictError     = "error"
+)
+
+// Result is the outcome of polling a single MonitorTarget. TimeToSuccess is
+// only set when the expected response was seen, and Error holds the last
+// error seen while polling. Response is the last body that did not match the
+// expected response. Duration is how long the target was polled for, History
+// holds every poll in order and Latency how long each poll took to answer.
+type Result struct {
+	Name          string               `json:"name"`
+	Method        string               `json:"method"`
+	Url           string               `json:"url"`
+	Verdict       string               `json:"verdict"`
+	Polls         int                  `json:"polls"`
+	TimeToSuccess duration.Duration    `json:"timeToSuccess,omitempty"`
+	Duration      duration.Duration    `json:"duration"`
+	Error         string               `json:"error,omitempty"`
+	Response      string               `json:"response,omitempty"`
+	History       []*Poll              `json:"history"`
+	Latency       *histogram.Histogram `json:"-"`
+}
+
+// Poll is a single request sent to a monitored target. Matched is set when
+// the response was the expected one.
+type Poll struct {
+	StartedAt  time.Time         `json:"startedAt"`
+	Latency    duration.Duration `json:"latency"`
+	StatusCode int               `json:"statusCode,omitempty"`
+	Matched    bool              `json:"matched"`
+	Error      string            `json:"error,omitempty"`
+}
+
+func (r *Result) Satisfied() bool {
+	return r.Verdict == VerdictSatisfied
+}
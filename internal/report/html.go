@@ -0,0 +1,320 @@
+package report
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+)
+
+// HTMLMaxFailures is how many failed requests the HTML report shows in full
+// for each simulation.
+const HTMLMaxFailures = 20
+
+const (
+	chartWidth    = 800
+	chartHeight   = 240
+	chartLeft     = 70
+	chartBottom   = 30
+	timelineLeft  = 220
+	timelineRow   = 22
+	timelineRows  = 100
+	chartTicks    = 5
+	timelineWidth = chartWidth
+)
+
+var chartColors = []string{"#2563eb", "#d97706", "#059669", "#dc2626", "#7c3aed", "#db2777", "#0891b2", "#65a30d"}
+
+//go:embed html.tmpl
+var htmlTemplates embed.FS
+
+var htmlTemplate = template.Must(template.New("html.tmpl").Funcs(template.FuncMap{
+	"duration": formatDuration,
+	"percent": func(part int, total int) string {
+		if total == 0 {
+			return "0.0"
+		}
+		return fmt.Sprintf("%.1f", float64(part)/float64(total)*100)
+	},
+}).ParseFS(htmlTemplates, "html.tmpl"))
+
+type htmlPage struct {
+	GeneratedAt time.Time
+	Simulations []*htmlSimulation
+}
+
+type htmlSimulation struct {
+	Result       *simulation.SimulationResult
+	Config       string
+	Latency      *svgChart
+	Statuses     []*statusCount
+	Timeline     *svgTimeline
+	Failures     []*simulation.RequestResult
+	MoreFailures int
+}
+
+type statusCount struct {
+	Label string
+	Count int
+	Total int
+}
+
+type svgChart struct {
+	Width  int
+	Height int
+	Left   int
+	Bottom int
+	XTicks []svgTick
+	YTicks []svgTick
+	Series []*svgSeries
+}
+
+type svgTick struct {
+	Pos   float64
+	Label string
+}
+
+type svgSeries struct {
+	Name   string
+	Color  string
+	Points []svgPoint
+}
+
+type svgPoint struct {
+	X     float64
+	Y     float64
+	Color string
+	Title string
+}
+
+type svgTimeline struct {
+	Width   int
+	Height  int
+	Left    int
+	XTicks  []svgTick
+	Rows    []*svgTimelineRow
+	Omitted int
+}
+
+type svgTimelineRow struct {
+	Label string
+	Y     float64
+	Polls []svgPoint
+}
+
+// WriteHTML writes results as a single self-contained HTML page, with no
+// scripts or external assets, so it can be attached to tickets and kept as a
+// CI artifact.
+func WriteHTML(w io.Writer, results []*simulation.SimulationResult) error {
+	page := &htmlPage{GeneratedAt: time.Now()}
+	for _, result := range results {
+		sim, err := newHTMLSimulation(result)
+		if err != nil {
+			return err
+		}
+		page.Simulations = append(page.Simulations, sim)
+	}
+
+	return htmlTemplate.Execute(w, page)
+}
+
+func newHTMLSimulation(result *simulation.SimulationResult) (*htmlSimulation, error) {
+	sim := &htmlSimulation{
+		Result:   result,
+		Latency:  latencyChart(result),
+		Statuses: statusCounts(result),
+		Timeline: monitorTimeline(result),
+	}
+
+	if result.Config != nil {
+		config, err := json.MarshalIndent(result.Config, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode config of %s: %w", result.Name, err)
+		}
+		sim.Config = string(config)
+	}
+
+	for _, request := range result.Requests {
+		if !request.Failed {
+			continue
+		}
+
+		if len(sim.Failures) < HTMLMaxFailures {
+			sim.Failures = append(sim.Failures, request)
+		} else {
+			sim.MoreFailures++
+		}
+	}
+
+	// A sampled result counts failed requests it did not keep.
+	if result.Sampled {
+		sim.MoreFailures = max(sim.MoreFailures, result.Summary.FailedRequests-len(sim.Failures))
+	}
+
+	return sim, nil
+}
+
+// latencyChart plots the latency of every request against when it started,
+// with a series for each target.
+func latencyChart(result *simulation.SimulationResult) *svgChart {
+	chart := &svgChart{Width: chartWidth, Height: chartHeight, Left: chartLeft, Bottom: chartBottom}
+
+	span := result.Duration.Std()
+	var slowest time.Duration
+	for _, request := range result.Requests {
+		span = max(span, request.StartedAt.Sub(result.StartedAt))
+		slowest = max(slowest, request.Latency.Std())
+	}
+
+	plotWidth := float64(chart.Width - chart.Left)
+	plotHeight := float64(chart.Height - chart.Bottom)
+	chart.XTicks = ticks(span, plotWidth, func(pos float64) float64 { return float64(chart.Left) + pos })
+	chart.YTicks = ticks(slowest, plotHeight, func(pos float64) float64 { return plotHeight - pos })
+
+	for i, target := range result.Targets {
+		series := &svgSeries{Name: target.Name, Color: chartColors[i%len(chartColors)]}
+		for _, request := range result.Requests {
+			if request.Target != target.Name {
+				continue
+			}
+
+			series.Points = append(series.Points, svgPoint{
+				X:     float64(chart.Left) + scale(request.StartedAt.Sub(result.StartedAt), span, plotWidth),
+				Y:     plotHeight - scale(request.Latency.Std(), slowest, plotHeight),
+				Color: series.Color,
+				Title: fmt.Sprintf("%s %s: %s %s", request.Step, request.Method, formatDuration(request.Latency.Std()), statusLabel(request.StatusCode)),
+			})
+		}
+		chart.Series = append(chart.Series, series)
+	}
+
+	return chart
+}
+
+// monitorTimeline draws a row for every monitored target with a mark for
+// each poll, placed at the time it was sent.
+func monitorTimeline(result *simulation.SimulationResult) *svgTimeline {
+	if len(result.Monitors) == 0 {
+		return nil
+	}
+
+	span := result.Duration.Std()
+	for _, m := range result.Monitors {
+		for _, poll := range m.History {
+			span = max(span, poll.StartedAt.Sub(result.StartedAt))
+		}
+	}
+
+	monitors := result.Monitors
+	timeline := &svgTimeline{Width: timelineWidth, Left: timelineLeft}
+	if len(monitors) > timelineRows {
+		timeline.Omitted = len(monitors) - timelineRows
+		monitors = monitors[:timelineRows]
+	}
+	timeline.Height = len(monitors)*timelineRow + chartBottom
+
+	plotWidth := float64(timeline.Width - timeline.Left)
+	timeline.XTicks = ticks(span, plotWidth, func(pos float64) float64 { return float64(timeline.Left) + pos })
+
+	for i, m := range monitors {
+		row := &svgTimelineRow{
+			Label: fmt.Sprintf("%s/%s client %d: %s", m.Target, m.Name, m.Client, m.Verdict),
+			Y:     float64(i*timelineRow + timelineRow/2),
+		}
+
+		for n, poll := range m.History {
+			color, outcome := "#9ca3af", "did not match"
+			switch {
+			case poll.Matched:
+				color, outcome = "#059669", "matched"
+			case poll.Error != "":
+				color, outcome = "#dc2626", poll.Error
+			}
+
+			row.Polls = append(row.Polls, svgPoint{
+				X:     float64(timeline.Left) + scale(poll.StartedAt.Sub(result.StartedAt), span, plotWidth),
+				Y:     row.Y,
+				Color: color,
+				Title: fmt.Sprintf("poll %d at +%s: %s %s", n+1, formatDuration(poll.StartedAt.Sub(result.StartedAt)), statusLabel(poll.StatusCode), outcome),
+			})
+		}
+		timeline.Rows = append(timeline.Rows, row)
+	}
+
+	return timeline
+}
+
+// statusCounts counts requests by status code, from the counts of the run or
+// from its requests for a result without them.
+func statusCounts(result *simulation.SimulationResult) []*statusCount {
+	counts := result.Statuses
+	if counts == nil {
+		counts = map[int]int{}
+		for _, request := range result.Requests {
+			counts[request.StatusCode]++
+		}
+	}
+
+	total := 0
+	codes := make([]int, 0, len(counts))
+	for code, count := range counts {
+		codes = append(codes, code)
+		total += count
+	}
+	slices.Sort(codes)
+
+	statuses := make([]*statusCount, 0, len(codes))
+	for _, code := range codes {
+		statuses = append(statuses, &statusCount{Label: statusLabel(code), Count: counts[code], Total: total})
+	}
+
+	return statuses
+}
+
+func statusLabel(code int) string {
+	if code == 0 {
+		return "no response"
+	}
+
+	return fmt.Sprintf("%d %s", code, http.StatusText(code))
+}
+
+// ticks returns evenly spaced ticks from 0 to limit along an axis of length
+// size, using place to turn a distance along the axis into a coordinate.
+func ticks(limit time.Duration, size float64, place func(float64) float64) []svgTick {
+	result := make([]svgTick, 0, chartTicks+1)
+	for i := range chartTicks + 1 {
+		d := limit * time.Duration(i) / chartTicks
+		result = append(result, svgTick{
+			Pos:   place(scale(d, limit, size)),
+			Label: formatDuration(d),
+		})
+	}
+
+	return result
+}
+
+func scale(d time.Duration, limit time.Duration, size float64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+
+	return float64(d) / float64(limit) * size
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
@@ -0,0 +1,114 @@
+package report_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/monitor"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/report"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+)
+
+func TestWriteHTML(t *testing.T) {
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	result := &simulation.SimulationResult{
+		Name:      "Orders",
+		StartedAt: started,
+		Duration:  duration.Duration(time.Second),
+		Config: &simulation.SimulationConfig{
+			Name:   "Orders",
+			Target: simulation.SimulationTargetConfig{Count: 1, Client: &api.ClientConfig{Url: "http://localhost/orders"}},
+		},
+		Targets: []*simulation.TargetResult{{Name: "orders"}},
+		Monitors: []*simulation.MonitorResult{
+			{Target: "orders", Step: "create", Result: &monitor.Result{Name: "status", Verdict: monitor.VerdictSatisfied, History: []*monitor.Poll{
+				{StartedAt: started.Add(100 * time.Millisecond), StatusCode: 200},
+				{StartedAt: started.Add(300 * time.Millisecond), StatusCode: 200, Matched: true},
+			}}},
+		},
+		Latency: []*simulation.LatencyResult{{Kind: simulation.LatencyKindTarget, Name: "orders", Count: 25}},
+	}
+
+	for i := range 25 {
+		request := &simulation.RequestResult{
+			Target:     "orders",
+			Step:       "create",
+			Client:     i,
+			Method:     "POST",
+			Url:        "http://localhost/orders",
+			StartedAt:  started.Add(time.Duration(i) * 10 * time.Millisecond),
+			StatusCode: 201,
+			Latency:    duration.Duration(time.Duration(i+1) * time.Millisecond),
+		}
+		if i%5 == 0 {
+			request.StatusCode = 500
+			request.Failed = true
+			request.Error = "unexpected status 500 Internal Server Error"
+			request.RequestBody = fmt.Sprintf(`{"client":%d}`, i)
+			request.Response = `<h1>oops</h1>`
+		}
+		result.Requests = append(result.Requests, request)
+	}
+
+	var sb strings.Builder
+	if err := report.WriteHTML(&sb, []*simulation.SimulationResult{result}); err != nil {
+		t.Fatalf("WriteHTML() error: %v", err)
+	}
+	html := sb.String()
+
+	for _, want := range []string{
+		"<h2>Orders</h2>",
+		"http://localhost/orders",
+		"201 Created</td><td class=\"num\">20</td>",
+		"500 Internal Server Error</td><td class=\"num\">5</td>",
+		"poll 2 at &#43;300ms: 200 OK matched",
+		`{&#34;client&#34;:20}`,
+		"&lt;h1&gt;oops&lt;/h1&gt;",
+		"<circle",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+
+	for _, unwanted := range []string{"<script", "ZgotmplZ", "<h1>oops"} {
+		if strings.Contains(html, unwanted) {
+			t.Errorf("report contains %q", unwanted)
+		}
+	}
+}
+
+func TestWriteHTMLSampled(t *testing.T) {
+	result := &simulation.SimulationResult{
+		Name:     "Soak",
+		Duration: duration.Duration(time.Minute),
+		Summary:  simulation.Summary{Requests: 1000, FailedRequests: 30},
+		Statuses: map[int]int{200: 970, 500: 30},
+		Sampled:  true,
+		Targets:  []*simulation.TargetResult{{Name: "orders"}},
+	}
+	for i := range 25 {
+		result.Requests = append(result.Requests, &simulation.RequestResult{Target: "orders", Step: "create", Client: i, Method: "POST", Url: "http://localhost/orders", StatusCode: 500, Failed: true})
+	}
+
+	var sb strings.Builder
+	if err := report.WriteHTML(&sb, []*simulation.SimulationResult{result}); err != nil {
+		t.Fatalf("WriteHTML() error: %v", err)
+	}
+	html := sb.String()
+
+	for _, want := range []string{
+		"200 OK</td><td class=\"num\">970</td><td class=\"num\">97.0%</td>",
+		"500 Internal Server Error</td><td class=\"num\">30</td>",
+		"Only the first 25 failed request(s) of this duration-bound run were kept",
+		"10 more failed request(s) not shown.",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+}
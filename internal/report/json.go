@@ -0,0 +1,43 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+)
+
+// ResultsVersion is the version of the JSON results format written by
+// WriteJSON.
+const ResultsVersion = 1
+
+// Results is the JSON results format, holding every simulation of a run so it
+// can be read back later, for example to compare two runs.
+type Results struct {
+	Version     int                            `json:"version"`
+	Simulations []*simulation.SimulationResult `json:"simulations"`
+}
+
+// WriteJSON writes results in the JSON results format.
+func WriteJSON(w io.Writer, results []*simulation.SimulationResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(&Results{Version: ResultsVersion, Simulations: results})
+}
+
+// ReadJSON reads results written by WriteJSON.
+func ReadJSON(r io.Reader) ([]*simulation.SimulationResult, error) {
+	var results Results
+	if err := json.NewDecoder(r).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to decode results: %w", err)
+	}
+
+	if results.Version != ResultsVersion {
+		return nil, fmt.Errorf("unsupported results version %d, expected %d", results.Version, ResultsVersion)
+	}
+
+	return results.Simulations, nil
+}
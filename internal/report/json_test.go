@@ -0,0 +1,52 @@
+package report_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/monitor"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/report"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	result := &simulation.SimulationResult{
+		Name:     "Orders",
+		Duration: duration.Duration(2 * time.Second),
+		Summary:  simulation.Summary{Requests: 2, FailedRequests: 1},
+		Targets:  []*simulation.TargetResult{{Name: "orders", Summary: simulation.Summary{Requests: 2}}},
+		Requests: []*simulation.RequestResult{{Target: "orders", Url: "http://localhost/orders?id=<id>", Latency: duration.Duration(5 * time.Millisecond)}},
+		Monitors: []*simulation.MonitorResult{{Target: "orders", Result: &monitor.Result{Name: "status", Verdict: monitor.VerdictSatisfied, TimeToSuccess: duration.Duration(time.Second)}}},
+		Latency:  []*simulation.LatencyResult{{Kind: simulation.LatencyKindTarget, Name: "orders", P95: duration.Duration(7 * time.Millisecond)}},
+	}
+
+	var sb strings.Builder
+	if err := report.WriteJSON(&sb, []*simulation.SimulationResult{result}); err != nil {
+		t.Fatalf("WriteJSON() error: %v", err)
+	}
+
+	results, err := report.ReadJSON(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatalf("ReadJSON() error: %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("ReadJSON() returned %d results, want 1", len(results))
+	}
+
+	got := results[0]
+	if got.Name != "Orders" || got.Summary != result.Summary || got.Targets[0].Requests != 2 || got.Requests[0].Url != result.Requests[0].Url {
+		t.Errorf("ReadJSON() = %+v", got)
+	}
+	if got.Latency[0].P95 != result.Latency[0].P95 || got.Monitors[0].TimeToSuccess != result.Monitors[0].TimeToSuccess {
+		t.Errorf("ReadJSON() lost latency or monitors: %+v, %+v", got.Latency[0], got.Monitors[0].Result)
+	}
+}
+
+func TestReadJSONVersion(t *testing.T) {
+	if _, err := report.ReadJSON(strings.NewReader(`{"version": 99, "simulations": []}`)); err == nil {
+		t.Errorf("ReadJSON() should reject an unknown version")
+	}
+}
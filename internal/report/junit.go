@@ -0,0 +1,268 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Errors   int               `xml:"errors,attr"`
+	Time     string            `xml:"time,attr"`
+	Suites   []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Errors    int              `xml:"errors,attr"`
+	Time      string           `xml:"time,attr"`
+	Timestamp string           `xml:"timestamp,attr"`
+	Cases     []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// maxJUnitFailures is how many failing requests or monitors a testcase lists
+// before the rest are only counted.
+const maxJUnitFailures = 10
+
+// WriteJUnit writes results as JUnit XML. Each simulation is a testsuite,
+// with a testcase for every target, step, monitor and threshold. A failing
+// request only fails the testcase of its step, so it is counted once. Failed
+// testcases list the first failing requests or monitors along with the
+// response that did not match. A sampled result only keeps failures, so only
+// the steps and monitors that failed get a testcase.
+func WriteJUnit(w io.Writer, results []*simulation.SimulationResult) error {
+	suites := &junitTestSuites{Name: "easy-test"}
+
+	var elapsed time.Duration
+	for _, result := range results {
+		suite := newJUnitTestSuite(result)
+		suites.Suites = append(suites.Suites, suite)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		elapsed += result.Duration.Std()
+	}
+	suites.Time = seconds(elapsed)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func newJUnitTestSuite(result *simulation.SimulationResult) *junitTestSuite {
+	suite := &junitTestSuite{
+		Name:      result.Name,
+		Time:      seconds(result.Duration.Std()),
+		Timestamp: result.StartedAt.Format("2006-01-02T15:04:05"),
+	}
+
+	for _, target := range result.Targets {
+		requests := filter(result.Requests, func(r *simulation.RequestResult) bool { return r.Target == target.Name })
+		suite.add(targetCase("target "+target.Name, result.Name, requests))
+
+		for _, step := range groupBy(requests, func(r *simulation.RequestResult) string { return r.Step }) {
+			suite.add(requestCase("step "+step.key, result.Name+"."+target.Name, step.items))
+		}
+
+		monitors := filter(result.Monitors, func(m *simulation.MonitorResult) bool { return m.Target == target.Name })
+		for _, m := range groupBy(monitors, func(m *simulation.MonitorResult) string { return m.Step + "\x00" + m.Name }) {
+			step, name, _ := strings.Cut(m.key, "\x00")
+			suite.add(monitorCase("monitor "+name, result.Name+"."+target.Name+"."+step, m.items))
+		}
+	}
+
+	for _, threshold := range result.Thresholds {
+		testCase := &junitTestCase{Name: "threshold " + threshold.Name, Classname: result.Name, Time: seconds(0)}
+		if !threshold.Passed {
+			testCase.Failure = &junitProblem{Message: threshold.String(), Type: "ThresholdBreached", Text: threshold.String()}
+		}
+		suite.add(testCase)
+	}
+
+	if result.TimedOut {
+		message := fmt.Sprintf("simulation timed out after %s", result.Duration)
+		suite.add(&junitTestCase{
+			Name:      "timeout",
+			Classname: result.Name,
+			Time:      seconds(result.Duration.Std()),
+			Error:     &junitProblem{Message: message, Type: "TimedOut", Text: message},
+		})
+	}
+
+	return suite
+}
+
+func (s *junitTestSuite) add(testCase *junitTestCase) {
+	s.Cases = append(s.Cases, testCase)
+	s.Tests++
+	if testCase.Failure != nil {
+		s.Failures++
+	}
+	if testCase.Error != nil {
+		s.Errors++
+	}
+}
+
+func targetCase(name string, classname string, requests []*simulation.RequestResult) *junitTestCase {
+	var elapsed time.Duration
+	for _, request := range requests {
+		elapsed += request.Latency.Std()
+	}
+
+	return &junitTestCase{Name: name, Classname: classname, Time: seconds(elapsed)}
+}
+
+func requestCase(name string, classname string, requests []*simulation.RequestResult) *junitTestCase {
+	var elapsed time.Duration
+	var failed []*simulation.RequestResult
+	for _, request := range requests {
+		elapsed += request.Latency.Std()
+		if request.Failed {
+			failed = append(failed, request)
+		}
+	}
+
+	testCase := &junitTestCase{Name: name, Classname: classname, Time: seconds(elapsed)}
+	if len(failed) == 0 {
+		return testCase
+	}
+
+	var sb strings.Builder
+	for _, request := range failed[:min(len(failed), maxJUnitFailures)] {
+		fmt.Fprintf(&sb, "attempt %d, client %d, step %s: %s %s", request.Attempt, request.Client, request.Step, request.Method, request.Url)
+		if request.StatusCode != 0 {
+			fmt.Fprintf(&sb, " returned %d", request.StatusCode)
+		}
+		fmt.Fprintf(&sb, "\n  %s\n", request.Error)
+		if request.RequestBody != "" {
+			fmt.Fprintf(&sb, "  request: %s\n", request.RequestBody)
+		}
+		if request.Response != "" {
+			fmt.Fprintf(&sb, "  response: %s\n", request.Response)
+		}
+	}
+	writeOmitted(&sb, len(failed))
+
+	testCase.Failure = &junitProblem{
+		Message: fmt.Sprintf("%d of %d request(s) failed: %s", len(failed), len(requests), failed[0].Error),
+		Type:    "RequestFailed",
+		Text:    sb.String(),
+	}
+
+	return testCase
+}
+
+func monitorCase(name string, classname string, monitors []*simulation.MonitorResult) *junitTestCase {
+	var elapsed time.Duration
+	var failed []*simulation.MonitorResult
+	for _, m := range monitors {
+		elapsed += m.Duration.Std()
+		if !m.Satisfied() {
+			failed = append(failed, m)
+		}
+	}
+
+	testCase := &junitTestCase{Name: name, Classname: classname, Time: seconds(elapsed)}
+	if len(failed) == 0 {
+		return testCase
+	}
+
+	var sb strings.Builder
+	for _, m := range failed[:min(len(failed), maxJUnitFailures)] {
+		fmt.Fprintf(&sb, "attempt %d, client %d: %s %s %s after %d poll(s)\n", m.Attempt, m.Client, m.Method, m.Url, m.Verdict, m.Polls)
+		if m.Error != "" {
+			fmt.Fprintf(&sb, "  %s\n", m.Error)
+		}
+		if m.Response != "" {
+			fmt.Fprintf(&sb, "  last response: %s\n", m.Response)
+		}
+	}
+	writeOmitted(&sb, len(failed))
+
+	testCase.Failure = &junitProblem{
+		Message: fmt.Sprintf("%d of %d monitor(s) did not see the expected response", len(failed), len(monitors)),
+		Type:    "MonitorFailed",
+		Text:    sb.String(),
+	}
+
+	return testCase
+}
+
+// writeOmitted notes how many of failed were left out of the failure text.
+func writeOmitted(sb *strings.Builder, failed int) {
+	if failed > maxJUnitFailures {
+		fmt.Fprintf(sb, "... and %d more\n", failed-maxJUnitFailures)
+	}
+}
+
+type group[T any] struct {
+	key   string
+	items []T
+}
+
+// groupBy groups items by key, keeping groups in the order their first item
+// appears.
+func groupBy[T any](items []T, key func(T) string) []*group[T] {
+	var groups []*group[T]
+	index := map[string]*group[T]{}
+	for _, item := range items {
+		k := key(item)
+		g, ok := index[k]
+		if !ok {
+			g = &group[T]{key: k}
+			index[k] = g
+			groups = append(groups, g)
+		}
+		g.items = append(g.items, item)
+	}
+
+	return groups
+}
+
+func filter[T any](items []T, keep func(T) bool) []T {
+	var kept []T
+	for _, item := range items {
+		if keep(item) {
+			kept = append(kept, item)
+		}
+	}
+
+	return kept
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
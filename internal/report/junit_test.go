@@ -0,0 +1,133 @@
+package report_test
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/monitor"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/report"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
+)
+
+type testSuites struct {
+	Tests    int `xml:"tests,attr"`
+	Failures int `xml:"failures,attr"`
+	Suites   []struct {
+		Name     string `xml:"name,attr"`
+		Tests    int    `xml:"tests,attr"`
+		Failures int    `xml:"failures,attr"`
+		Errors   int    `xml:"errors,attr"`
+		Cases    []struct {
+			Name      string `xml:"name,attr"`
+			Classname string `xml:"classname,attr"`
+			Failure   *struct {
+				Message string `xml:"message,attr"`
+				Text    string `xml:",chardata"`
+			} `xml:"failure"`
+		} `xml:"testcase"`
+	} `xml:"testsuite"`
+}
+
+func TestWriteJUnit(t *testing.T) {
+	result := &simulation.SimulationResult{
+		Name:      "Orders",
+		StartedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Duration:  duration.Duration(2 * time.Second),
+		Targets:   []*simulation.TargetResult{{Name: "orders"}, {Name: "health"}},
+		Requests: []*simulation.RequestResult{
+			{Target: "orders", Step: "create", Method: "POST", Url: "http://localhost/orders", StatusCode: 201, Latency: duration.Duration(10 * time.Millisecond)},
+			{Target: "orders", Step: "create", Client: 1, Method: "POST", Url: "http://localhost/orders", StatusCode: 500, Failed: true, Error: "unexpected status 500 Internal Server Error", Response: `{"error":"database <down>"}`},
+			{Target: "health", Step: "step 1", Method: "GET", Url: "http://localhost/health", StatusCode: 200},
+		},
+		Monitors: []*simulation.MonitorResult{
+			{Target: "orders", Step: "create", Result: &monitor.Result{Name: "status", Method: "GET", Url: "http://localhost/orders/1", Verdict: monitor.VerdictExhausted, Polls: 3, Response: `{"status":"pending"}`}},
+		},
+		Thresholds: []*simulation.ThresholdResult{{Name: "errorRate", Limit: "1.00%", Actual: "33.33%", Passed: false}},
+	}
+
+	var sb strings.Builder
+	if err := report.WriteJUnit(&sb, []*simulation.SimulationResult{result}); err != nil {
+		t.Fatalf("WriteJUnit() error: %v", err)
+	}
+
+	var parsed testSuites
+	if err := xml.Unmarshal([]byte(sb.String()), &parsed); err != nil {
+		t.Fatalf("WriteJUnit() wrote invalid xml: %v\n%s", err, sb.String())
+	}
+
+	if len(parsed.Suites) != 1 || parsed.Suites[0].Name != "Orders" {
+		t.Fatalf("suites = %+v, want a single Orders suite", parsed.Suites)
+	}
+
+	suite := parsed.Suites[0]
+	want := []struct {
+		name      string
+		classname string
+		failed    bool
+		contains  string
+	}{
+		{name: "target orders", classname: "Orders"},
+		{name: "step create", classname: "Orders.orders", failed: true, contains: `database <down>`},
+		{name: "monitor status", classname: "Orders.orders.create", failed: true, contains: `last response: {"status":"pending"}`},
+		{name: "target health", classname: "Orders"},
+		{name: "step step 1", classname: "Orders.health"},
+		{name: "threshold errorRate", classname: "Orders", failed: true, contains: "33.33%"},
+	}
+
+	if len(suite.Cases) != len(want) {
+		t.Fatalf("got %d testcases, want %d:\n%s", len(suite.Cases), len(want), sb.String())
+	}
+
+	for i, w := range want {
+		c := suite.Cases[i]
+		if c.Name != w.name || c.Classname != w.classname {
+			t.Errorf("testcase %d = %s (%s), want %s (%s)", i, c.Name, c.Classname, w.name, w.classname)
+		}
+
+		if (c.Failure != nil) != w.failed {
+			t.Errorf("testcase %s failed = %v, want %v", w.name, c.Failure != nil, w.failed)
+			continue
+		}
+
+		if c.Failure != nil && !strings.Contains(c.Failure.Text, w.contains) {
+			t.Errorf("testcase %s failure = %q, want it to contain %q", w.name, c.Failure.Text, w.contains)
+		}
+	}
+
+	if suite.Tests != 6 || suite.Failures != 3 || parsed.Tests != 6 || parsed.Failures != 3 {
+		t.Errorf("counts = %d tests, %d failures, want 6 and 3", suite.Tests, suite.Failures)
+	}
+}
+
+func TestWriteJUnitLimitsFailures(t *testing.T) {
+	result := &simulation.SimulationResult{
+		Name:    "Soak",
+		Targets: []*simulation.TargetResult{{Name: "orders"}},
+	}
+	for i := range 15 {
+		result.Requests = append(result.Requests, &simulation.RequestResult{Target: "orders", Step: "create", Client: i, Method: "POST", Url: "http://localhost/orders", Failed: true, Error: "timeout"})
+	}
+
+	var sb strings.Builder
+	if err := report.WriteJUnit(&sb, []*simulation.SimulationResult{result}); err != nil {
+		t.Fatalf("WriteJUnit() error: %v", err)
+	}
+
+	var parsed testSuites
+	if err := xml.Unmarshal([]byte(sb.String()), &parsed); err != nil {
+		t.Fatalf("WriteJUnit() wrote invalid xml: %v\n%s", err, sb.String())
+	}
+
+	cases := parsed.Suites[0].Cases
+	if len(cases) != 2 || cases[0].Failure != nil || cases[1].Failure == nil {
+		t.Fatalf("testcases = %+v, want a passed target and a failed step", cases)
+	}
+
+	text := cases[1].Failure.Text
+	if strings.Count(text, "POST http://localhost/orders") != 10 || !strings.Contains(text, "... and 5 more") {
+		t.Errorf("step failure lists %d requests, want 10 and 5 more:\n%s", strings.Count(text, "POST http://localhost/orders"), text)
+	}
+}
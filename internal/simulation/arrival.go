@@ -0,0 +1,157 @@
+package simulation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/threadpool"
+)
+
+// Distributions of the time between iterations of an arrival rate run.
+const (
+	ArrivalConstant = "constant"
+	ArrivalPoisson  = "poisson"
+)
+
+// Arrival starts iterations at a rate whatever the response latency, rather
+// than a whole attempt at a time. Without stages, Rate iterations start every
+// second until every client of every attempt has started, or until the
+// duration of the simulation has passed. With stages, the stages set the rate
+// instead.
+//
+// Iterations are spaced evenly with the constant distribution, or at random
+// with the poisson distribution, which averages the same rate. An iteration
+// that is due while MaxInFlight iterations are still running, by default the
+// number of workers, is dropped rather than started late.
+type Arrival struct {
+	Rate         float64 `json:"rate,omitempty"`
+	Distribution string  `json:"distribution,omitempty"`
+	MaxInFlight  int     `json:"maxInFlight,omitempty"`
+}
+
+func (a *Arrival) Validate() error {
+	if a == nil {
+		return nil
+	}
+
+	var errs []error
+	if a.Rate < 0 {
+		errs = append(errs, fmt.Errorf("rate can not be negative, got %g", a.Rate))
+	}
+
+	switch a.DistributionOrDefault() {
+	case ArrivalConstant, ArrivalPoisson:
+	default:
+		errs = append(errs, fmt.Errorf("unsupported distribution %q, expected %s or %s", a.Distribution, ArrivalConstant, ArrivalPoisson))
+	}
+
+	if a.MaxInFlight < 0 {
+		errs = append(errs, fmt.Errorf("maxInFlight can not be negative, got %d", a.MaxInFlight))
+	}
+
+	if a.MaxInFlight > threadpool.MAX_WORKERS {
+		errs = append(errs, fmt.Errorf("maxInFlight can not be more than %d, got %d", threadpool.MAX_WORKERS, a.MaxInFlight))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (a *Arrival) DistributionOrDefault() string {
+	if a == nil || a.Distribution == "" {
+		return ArrivalConstant
+	}
+
+	return strings.ToLower(a.Distribution)
+}
+
+// arrivals reports whether the simulation starts iterations at a rate.
+func (s *Simulation) arrivals() bool {
+	return s.arrival != nil || s.stages.ByRate()
+}
+
+// maxInFlight returns how many iterations of an arrival rate run can run at
+// once.
+func (s *Simulation) maxInFlight() int {
+	if s.arrival != nil && s.arrival.MaxInFlight > 0 {
+		return min(s.arrival.MaxInFlight, threadpool.MAX_WORKERS)
+	}
+
+	return min(s.workers, threadpool.MAX_WORKERS)
+}
+
+// dropFunc records an iteration that was due but found every worker busy.
+type dropFunc func(target *SimulationTarget, attempt int, client int)
+
+// runArrivals starts iterations, each on the next client of the rotation, as
+// the rate adds up to them. It stops once the stages end or the duration has
+// passed or, without either, once every client of every attempt has started.
+func (s *Simulation) runArrivals(ctx context.Context, tp *threadpool.ThreadPool, newTask newTaskFunc, drop dropFunc) {
+	r := newRotation(s.schedule())
+
+	due := s.stages.Iterations
+	end := s.stages.Duration()
+	total := 0
+	if len(s.stages) == 0 {
+		rate := s.arrival.Rate
+		due = func(elapsed time.Duration) float64 { return rate * elapsed.Seconds() }
+		end = s.duration
+		if end == 0 {
+			total = s.attempts * len(r.targets)
+		}
+	}
+
+	gap := func() float64 { return 1 }
+	next := 0.0
+	if s.arrival.DistributionOrDefault() == ArrivalPoisson {
+		gap = rand.ExpFloat64
+		next = gap()
+	}
+
+	ticker := time.NewTicker(stageTick)
+	defer ticker.Stop()
+
+	start := time.Now()
+	n := 0
+	for {
+		elapsed := time.Since(start)
+		if end > 0 {
+			elapsed = min(elapsed, end)
+		}
+
+		for d := due(elapsed); next < d && (total == 0 || n < total); n++ {
+			next += gap()
+
+			// The worker is reserved before the task is built, so a dropped
+			// iteration uses up no data row and creates no user.
+			target, attempt, client := r.slot(n)
+			if !tp.Reserve() {
+				drop(target, attempt, client)
+				continue
+			}
+
+			task := newTask(target, attempt, client)
+			if task == nil {
+				tp.Unreserve()
+				return
+			}
+
+			if !tp.AddReserved(task) {
+				drop(target, attempt, client)
+			}
+		}
+
+		if (end > 0 && elapsed >= end) || (total > 0 && n >= total) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
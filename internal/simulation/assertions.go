@@ -0,0 +1,161 @@
+package simulation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/templating"
+)
+
+// Assertions are checked against the response of a step. Status lists the
+// accepted status codes, Headers the expected header values and Body a JSON
+// object that the response body must contain. Header and body values may be
+// templates rendered with the step variables.
+type Assertions struct {
+	Status  []int             `json:"status,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    map[string]any    `json:"body,omitempty"`
+}
+
+func (a *Assertions) Validate() error {
+	if a == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, status := range a.Status {
+		if status < 100 || status > 599 {
+			errs = append(errs, fmt.Errorf("status %d is not a valid HTTP status code", status))
+		}
+	}
+
+	for k, v := range a.Headers {
+		if !api.ValidHeaderName(k) {
+			errs = append(errs, fmt.Errorf("%q is not a valid header name", k))
+		}
+
+		if err := templating.Validate(v); err != nil {
+			errs = append(errs, fmt.Errorf("header %s: %w", k, err))
+		}
+	}
+
+	data, err := json.Marshal(a.Body)
+	if err == nil {
+		err = templating.Validate(string(data))
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("body: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// checksStatus reports whether the assertions decide which status codes are
+// acceptable.
+func (a *Assertions) checksStatus() bool {
+	return a != nil && len(a.Status) > 0
+}
+
+// Check returns an error describing every assertion the response does not
+// satisfy. A nil Assertions accepts any response.
+func (a *Assertions) Check(resp *http.Response, body []byte, vars map[string]any) error {
+	if a == nil {
+		return nil
+	}
+
+	var errs []error
+	if len(a.Status) > 0 && !slices.Contains(a.Status, resp.StatusCode) {
+		errs = append(errs, fmt.Errorf("expected status in %v, got %d", a.Status, resp.StatusCode))
+	}
+
+	for k, v := range a.Headers {
+		expected, err := templating.Render(v, vars)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if actual := resp.Header.Get(k); actual != expected {
+			errs = append(errs, fmt.Errorf("expected header %s to be %q, got %q", k, expected, actual))
+		}
+	}
+
+	if a.Body != nil {
+		if err := checkBody(a.Body, body, vars); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func checkBody(expected map[string]any, body []byte, vars map[string]any) error {
+	rendered, err := templating.RenderValue(expected, vars)
+	if err != nil {
+		return err
+	}
+
+	// Round trip the expectation through JSON so numbers compare the same
+	// way as in the decoded response.
+	data, err := json.Marshal(rendered)
+	if err != nil {
+		return err
+	}
+
+	var want any
+	if err := json.Unmarshal(data, &want); err != nil {
+		return err
+	}
+
+	var got any
+	if err := json.Unmarshal(body, &got); err != nil {
+		return fmt.Errorf("response body is not valid json: %w", err)
+	}
+
+	if !containsValue(got, want) {
+		return fmt.Errorf("expected response body to contain %s, got %s", data, body)
+	}
+
+	return nil
+}
+
+// containsValue reports whether got matches want, where objects in want only
+// need to be a subset of the matching object in got.
+func containsValue(got, want any) bool {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		for k, v := range w {
+			item, ok := g[k]
+			if !ok || !containsValue(item, v) {
+				return false
+			}
+		}
+
+		return true
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(g) != len(w) {
+			return false
+		}
+
+		for i := range w {
+			if !containsValue(g[i], w[i]) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return reflect.DeepEqual(got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package simulation
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/histogram"
+)
+
+// Kinds of latency a simulation reports on.
+const (
+	LatencyKindTarget  = "target"
+	LatencyKindMonitor = "monitor"
+)
+
+// LatencyResult summarises the latency histogram of a target's requests or a
+// monitor's polls. Throughput is the number of requests or polls per second
+// over the whole run. Target is only set for monitors, naming the target that
+// started them.
+type LatencyResult struct {
+	Kind       string            `json:"kind"`
+	Name       string            `json:"name"`
+	Target     string            `json:"target,omitempty"`
+	Count      int64             `json:"count"`
+	Min        duration.Duration `json:"min"`
+	Mean       duration.Duration `json:"mean"`
+	P50        duration.Duration `json:"p50"`
+	P90        duration.Duration `json:"p90"`
+	P95        duration.Duration `json:"p95"`
+	P99        duration.Duration `json:"p99"`
+	Max        duration.Duration `json:"max"`
+	Throughput float64           `json:"throughput"`
+}
+
+func newLatencyResult(kind string, name string, target string, h *histogram.Histogram, elapsed time.Duration) *LatencyResult {
+	result := &LatencyResult{
+		Kind:   kind,
+		Name:   name,
+		Target: target,
+		Count:  h.Count(),
+		Min:    duration.Duration(h.Min()),
+		Mean:   duration.Duration(h.Mean()),
+		P50:    duration.Duration(h.Percentile(50)),
+		P90:    duration.Duration(h.Percentile(90)),
+		P95:    duration.Duration(h.Percentile(95)),
+		P99:    duration.Duration(h.Percentile(99)),
+		Max:    duration.Duration(h.Max()),
+	}
+
+	if elapsed > 0 {
+		result.Throughput = float64(h.Count()) / elapsed.Seconds()
+	}
+
+	return result
+}
+
+// WriteSummary writes the counts of the run followed by a table of the
+// latency of every target and monitor.
+func (r *SimulationResult) WriteSummary(w io.Writer) error {
+	s := r.Summary
+	dropped := ""
+	if s.DroppedIterations > 0 {
+		dropped = fmt.Sprintf("; %d iteration(s) dropped", s.DroppedIterations)
+	}
+
+	if _, err := fmt.Fprintf(w, "Simulation %q finished in %s: %d request(s), %d failed; %d monitor(s), %d failed%s\n\n",
+		r.Name, formatLatency(r.Duration.Std()), s.Requests, s.FailedRequests, s.Monitors, s.FailedMonitors, dropped); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tNAME\tCOUNT\tMIN\tMEAN\tP50\tP90\tP95\tP99\tMAX\tRATE")
+
+	for _, l := range r.Latency {
+		name := l.Name
+		if l.Target != "" {
+			name = l.Target + "/" + l.Name
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%.2f/s\n",
+			l.Kind, name, l.Count,
+			formatLatency(l.Min.Std()), formatLatency(l.Mean.Std()),
+			formatLatency(l.P50.Std()), formatLatency(l.P90.Std()), formatLatency(l.P95.Std()), formatLatency(l.P99.Std()),
+			formatLatency(l.Max.Std()), l.Throughput)
+	}
+
+	return tw.Flush()
+}
+
+// formatLatency rounds d so the table stays readable.
+func formatLatency(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
@@ -0,0 +1,245 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/feeder"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/monitor"
+)
+
+// Plan describes everything a simulation would send, built without making
+// any network calls. Values a step would extract from a response are not
+// known ahead of time, so later requests show them as "<name>". How many
+// clients a staged or duration-bound simulation runs depends on how fast the
+// target responds, so its plan only has the first iteration of each client of
+// an attempt. An arrival rate simulation without stages spaces its clients
+// evenly at the rate, a poisson distribution spaces them the same on average.
+// Workers is how many clients can run at once, which the pool caps at
+// threadpool.MAX_WORKERS however many are asked for.
+type Plan struct {
+	Name        string            `json:"name"`
+	Attempts    int               `json:"attempts"`
+	Cadence     duration.Duration `json:"cadence"`
+	Duration    duration.Duration `json:"duration,omitempty"`
+	GracePeriod duration.Duration `json:"gracePeriod,omitempty"`
+	Stages      Stages            `json:"stages,omitempty"`
+	Arrival     *Arrival          `json:"arrival,omitempty"`
+	Workers     int               `json:"workers"`
+	Clients     []*ClientPlan     `json:"clients"`
+}
+
+// ClientPlan is one client of one attempt, starting Offset after the
+// simulation starts.
+type ClientPlan struct {
+	Offset  duration.Duration `json:"offset"`
+	Attempt int               `json:"attempt"`
+	Client  int               `json:"client"`
+	Target  string            `json:"target"`
+	Steps   []*StepPlan       `json:"steps"`
+}
+
+type StepPlan struct {
+	Name    string           `json:"name"`
+	Request *api.RequestPlan `json:"request,omitempty"`
+	Error   string           `json:"error,omitempty"`
+	Extract []string         `json:"extract,omitempty"`
+	Monitor *MonitorPlan     `json:"monitor,omitempty"`
+}
+
+type MonitorPlan struct {
+	Name    string                `json:"name"`
+	Targets []*monitor.TargetPlan `json:"targets"`
+	Errors  []string              `json:"errors,omitempty"`
+}
+
+// Plan resolves the templates of every request the simulation would send and
+// returns them in the order they would start.
+func (s *Simulation) Plan() *Plan {
+	plan := &Plan{
+		Name:        s.name,
+		Attempts:    s.attempts,
+		Cadence:     duration.Duration(s.cadence),
+		Duration:    duration.Duration(s.duration),
+		GracePeriod: duration.Duration(s.grace),
+		Stages:      s.stages,
+		Arrival:     s.arrival,
+		Workers:     s.stageWorkers(),
+	}
+
+	attempts := s.attempts
+	if len(s.stages) > 0 || s.duration > 0 {
+		attempts = 1
+	}
+
+	// Fresh feeders so planning uses the same rows a run would, without
+	// using up the rows of the simulation itself.
+	data := make(map[string]*feeder.Feeder, len(s.data))
+	for name, f := range s.data {
+		data[name] = f.Fresh()
+	}
+
+	schedule := s.schedule()
+	for i := range attempts {
+		clients := make(map[*SimulationTarget]int, len(s.targets))
+		for j, target := range schedule {
+			vars := map[string]any{
+				ClientVar:    clients[target],
+				AttemptVar:   i,
+				IterationVar: i,
+			}
+
+			if err := nextRows(data, vars); err != nil {
+				s.logger.Warn("Ran out of data, not planning any more clients", "error", err)
+				return plan
+			}
+
+			offset := time.Duration(i) * s.cadence
+			if s.arrival != nil && len(s.stages) == 0 {
+				n := i*len(schedule) + j
+				offset = time.Duration(float64(n) / s.arrival.Rate * float64(time.Second))
+			}
+
+			clientPlan := &ClientPlan{
+				Offset:  duration.Duration(offset),
+				Attempt: i,
+				Client:  clients[target],
+				Target:  target.name,
+				Steps:   make([]*StepPlan, 0, len(target.steps)),
+			}
+			clients[target]++
+
+			for _, step := range target.steps {
+				clientPlan.Steps = append(clientPlan.Steps, step.Plan(vars))
+			}
+
+			plan.Clients = append(plan.Clients, clientPlan)
+		}
+	}
+
+	return plan
+}
+
+// maxInFlight returns how many iterations of an arrival rate plan can run at
+// once.
+func (p *Plan) maxInFlight() int {
+	if p.Arrival != nil && p.Arrival.MaxInFlight > 0 {
+		return p.Arrival.MaxInFlight
+	}
+
+	return p.Workers
+}
+
+func (p *Plan) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(p)
+}
+
+func (p *Plan) WriteText(w io.Writer) error {
+	var sb strings.Builder
+
+	if len(p.Stages) > 0 {
+		fmt.Fprintf(&sb, "Simulation %q: %d stage(s) over %s, first iteration of %d client(s), up to %d workers\n", p.Name, len(p.Stages), duration.Duration(p.Stages.Duration()), len(p.Clients), p.Workers)
+		if p.Arrival != nil {
+			fmt.Fprintf(&sb, "  arrivals: %s, up to %d in flight\n", p.Arrival.DistributionOrDefault(), p.maxInFlight())
+		}
+		for i, stage := range p.Stages {
+			fmt.Fprintf(&sb, "  stage %d: %s\n", i+1, stage)
+		}
+	} else if p.Duration > 0 && p.Arrival != nil {
+		fmt.Fprintf(&sb, "Simulation %q: %s of iterations arriving at %g iteration(s)/s (%s), %s grace period, first iteration of %d client(s), up to %d in flight\n", p.Name, p.Duration, p.Arrival.Rate, p.Arrival.DistributionOrDefault(), p.GracePeriod, len(p.Clients), p.maxInFlight())
+	} else if p.Duration > 0 {
+		fmt.Fprintf(&sb, "Simulation %q: %d client(s) iterating for %s, %s grace period, up to %d workers\n", p.Name, len(p.Clients), p.Duration, p.GracePeriod, p.Workers)
+		if p.Cadence > 0 {
+			fmt.Fprintf(&sb, "  each client starts iterations at least %s apart\n", p.Cadence)
+		}
+	} else if p.Arrival != nil {
+		fmt.Fprintf(&sb, "Simulation %q: %d attempt(s) arriving at %g iteration(s)/s (%s), %d client(s), up to %d in flight\n", p.Name, p.Attempts, p.Arrival.Rate, p.Arrival.DistributionOrDefault(), len(p.Clients), p.maxInFlight())
+	} else {
+		fmt.Fprintf(&sb, "Simulation %q: %d attempt(s) %s apart, %d client(s), up to %d workers\n", p.Name, p.Attempts, p.Cadence, len(p.Clients), p.Workers)
+	}
+
+	for _, client := range p.Clients {
+		fmt.Fprintf(&sb, "\n+%s attempt %d, client %d of target %q\n", client.Offset, client.Attempt, client.Client, client.Target)
+
+		for i, step := range client.Steps {
+			if step.Error != "" {
+				fmt.Fprintf(&sb, "  %d. %s: error: %s\n", i+1, step.Name, step.Error)
+				continue
+			}
+
+			if step.Request != nil {
+				fmt.Fprintf(&sb, "  %d. %s: %s %s\n", i+1, step.Name, step.Request.Method, step.Request.Url)
+				writeRequestDetails(&sb, step.Request, "       ")
+			} else {
+				fmt.Fprintf(&sb, "  %d. %s: no request\n", i+1, step.Name)
+			}
+
+			if len(step.Extract) > 0 {
+				fmt.Fprintf(&sb, "       extracts: %s\n", strings.Join(step.Extract, ", "))
+			}
+
+			if step.Monitor == nil {
+				continue
+			}
+
+			fmt.Fprintf(&sb, "       monitor %q:\n", step.Monitor.Name)
+			for _, target := range step.Monitor.Targets {
+				fmt.Fprintf(&sb, "         %s %s every %s, up to %d time(s) (%s)\n", target.Request.Method, target.Request.Url, target.Freq, target.Retries, target.Duration)
+				writeRequestDetails(&sb, target.Request, "           ")
+
+				if target.Expected != nil {
+					fmt.Fprintf(&sb, "           expects: %s\n", compactJSON(target.Expected))
+				}
+			}
+
+			for _, err := range step.Monitor.Errors {
+				fmt.Fprintf(&sb, "         error: %s\n", err)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeRequestDetails(sb *strings.Builder, request *api.RequestPlan, indent string) {
+	names := make([]string, 0, len(request.Headers))
+	for k := range request.Headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		for _, v := range request.Headers[k] {
+			fmt.Fprintf(sb, "%s%s: %s\n", indent, k, v)
+		}
+	}
+
+	if request.Body != "" {
+		fmt.Fprintf(sb, "%sbody: %s\n", indent, request.Body)
+	}
+}
+
+// compactJSON encodes v on a single line, leaving placeholders such as "<id>"
+// unescaped.
+func compactJSON(v any) string {
+	var sb strings.Builder
+	encoder := json.NewEncoder(&sb)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
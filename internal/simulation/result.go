@@ -0,0 +1,292 @@
+package simulation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/histogram"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/monitor"
+)
+
+// MaxSampledFailures is how many failed requests, and how many failed
+// monitors, the result of a duration-bound run keeps.
+const MaxSampledFailures = 100
+
+// SimulationResult records everything that happened during a simulation run.
+// TimedOut is set when the run was stopped by its deadline, in which case the
+// results only cover the clients that ran before it. Config is the
+// configuration the simulation was built from, when there was one.
+//
+// Statuses counts every request by the status code it got, 0 for none. A
+// duration-bound run can send any number of requests, so its result is
+// Sampled: Requests and Monitors only hold the first MaxSampledFailures
+// failures of each, while the counts, latency and consistency still cover
+// everything.
+type SimulationResult struct {
+	Name        string               `json:"name"`
+	Config      *SimulationConfig    `json:"config,omitempty"`
+	StartedAt   time.Time            `json:"startedAt"`
+	Duration    duration.Duration    `json:"duration"`
+	TimedOut    bool                 `json:"timedOut"`
+	Summary     Summary              `json:"summary"`
+	Statuses    map[int]int          `json:"statuses,omitempty"`
+	Sampled     bool                 `json:"sampled,omitempty"`
+	Targets     []*TargetResult      `json:"targets"`
+	Requests    []*RequestResult     `json:"requests"`
+	Monitors    []*MonitorResult     `json:"monitors"`
+	Latency     []*LatencyResult     `json:"latency"`
+	Consistency []*ConsistencyResult `json:"consistency,omitempty"`
+	Thresholds  []*ThresholdResult   `json:"thresholds,omitempty"`
+
+	// latency holds the latency of every request of the run, the histogram
+	// thresholds are checked against.
+	latency *histogram.Histogram
+}
+
+// Summary holds the aggregate counts of a run or a single target. An
+// iteration is one client running every step of a target, and fails when a
+// step fails and the remaining steps are skipped. An arrival rate run drops
+// an iteration that is due while every worker is busy.
+type Summary struct {
+	Iterations        int   `json:"iterations"`
+	FailedIterations  int   `json:"failedIterations"`
+	DroppedIterations int   `json:"droppedIterations,omitempty"`
+	Requests          int   `json:"requests"`
+	FailedRequests    int   `json:"failedRequests"`
+	Bytes             int64 `json:"bytes"`
+	Monitors          int   `json:"monitors"`
+	SatisfiedMonitors int   `json:"satisfiedMonitors"`
+	FailedMonitors    int   `json:"failedMonitors"`
+}
+
+type TargetResult struct {
+	Name string `json:"name"`
+	Summary
+}
+
+// RequestResult records a single request sent by a step. A request failed
+// when it could not be sent or read, an assertion did not hold, or it got an
+// error status that no status assertion accepted. RequestBody and Response
+// hold the bodies sent and received by a failed request.
+type RequestResult struct {
+	Target      string            `json:"target"`
+	Step        string            `json:"step"`
+	Attempt     int               `json:"attempt"`
+	Client      int               `json:"client"`
+	Method      string            `json:"method"`
+	Url         string            `json:"url"`
+	StartedAt   time.Time         `json:"startedAt"`
+	StatusCode  int               `json:"statusCode,omitempty"`
+	Latency     duration.Duration `json:"latency"`
+	Bytes       int64             `json:"bytes"`
+	Failed      bool              `json:"failed"`
+	Error       string            `json:"error,omitempty"`
+	RequestBody string            `json:"requestBody,omitempty"`
+	Response    string            `json:"response,omitempty"`
+	Extracted   map[string]any    `json:"extracted,omitempty"`
+}
+
+// MonitorResult records a monitored target started by a step.
+type MonitorResult struct {
+	Target  string `json:"target"`
+	Step    string `json:"step"`
+	Attempt int    `json:"attempt"`
+	Client  int    `json:"client"`
+	*monitor.Result
+}
+
+// ConsistencyResult is how long a monitor of a step took to see its expected
+// response, over the Satisfied times it did.
+type ConsistencyResult struct {
+	Target    string            `json:"target"`
+	Step      string            `json:"step"`
+	Name      string            `json:"name"`
+	Satisfied int               `json:"satisfied"`
+	Mean      duration.Duration `json:"mean"`
+	Max       duration.Duration `json:"max"`
+}
+
+// StepResult is what a single step did, before it is tied to a target and
+// client. Request is nil for a step that only monitors.
+type StepResult struct {
+	Step     string
+	Request  *RequestResult
+	Monitors []*monitor.Result
+}
+
+func (s *Summary) add(steps []*StepResult, failed bool) {
+	s.Iterations++
+	if failed {
+		s.FailedIterations++
+	}
+
+	for _, step := range steps {
+		if step.Request != nil {
+			s.Requests++
+			s.Bytes += step.Request.Bytes
+			if step.Request.Failed {
+				s.FailedRequests++
+			}
+		}
+
+		for _, m := range step.Monitors {
+			s.Monitors++
+			if m.Satisfied() {
+				s.SatisfiedMonitors++
+			} else {
+				s.FailedMonitors++
+			}
+		}
+	}
+}
+
+// recorder collects the results of every task of a run. A sampling recorder
+// keeps only the first MaxSampledFailures failed requests and monitors.
+type recorder struct {
+	mutex       sync.Mutex
+	result      *SimulationResult
+	targets     map[*SimulationTarget]*TargetResult
+	targetOrder []*SimulationTarget
+	latencies   map[*SimulationTarget]*histogram.Histogram
+	monitors    []*monitorLatency
+}
+
+// monitorLatency is the latency of every poll of one monitor of a step, and
+// the time it took to be satisfied.
+type monitorLatency struct {
+	target        string
+	step          string
+	name          string
+	latency       *histogram.Histogram
+	satisfied     int
+	timeToSuccess time.Duration
+	maxToSuccess  time.Duration
+}
+
+func newRecorder(name string, targets []*SimulationTarget, sample bool) *recorder {
+	r := &recorder{
+		result: &SimulationResult{
+			Name:      name,
+			StartedAt: time.Now(),
+			Statuses:  map[int]int{},
+			Sampled:   sample,
+			Targets:   make([]*TargetResult, 0, len(targets)),
+			Requests:  []*RequestResult{},
+			Monitors:  []*MonitorResult{},
+		},
+		targets:     make(map[*SimulationTarget]*TargetResult, len(targets)),
+		targetOrder: targets,
+		latencies:   make(map[*SimulationTarget]*histogram.Histogram, len(targets)),
+	}
+
+	for _, target := range targets {
+		targetResult := &TargetResult{Name: target.name}
+		r.targets[target] = targetResult
+		r.latencies[target] = histogram.New()
+		r.result.Targets = append(r.result.Targets, targetResult)
+	}
+
+	return r
+}
+
+// record adds the steps one client ran against target.
+func (r *recorder) record(target *SimulationTarget, attempt int, client int, steps []*StepResult, failed bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, step := range steps {
+		if step.Request != nil {
+			step.Request.Target = target.name
+			step.Request.Attempt = attempt
+			step.Request.Client = client
+			r.result.Statuses[step.Request.StatusCode]++
+			r.latencies[target].Record(step.Request.Latency.Std())
+			if r.keep(step.Request.Failed, len(r.result.Requests)) {
+				r.result.Requests = append(r.result.Requests, step.Request)
+			}
+		}
+
+		for _, m := range step.Monitors {
+			ml := r.monitorLatency(target.name, step.Step, m.Name)
+			ml.latency.Merge(m.Latency)
+			if m.Satisfied() {
+				ml.satisfied++
+				ml.timeToSuccess += m.TimeToSuccess.Std()
+				ml.maxToSuccess = max(ml.maxToSuccess, m.TimeToSuccess.Std())
+			}
+			if !r.keep(!m.Satisfied(), len(r.result.Monitors)) {
+				continue
+			}
+
+			r.result.Monitors = append(r.result.Monitors, &MonitorResult{
+				Target:  target.name,
+				Step:    step.Step,
+				Attempt: attempt,
+				Client:  client,
+				Result:  m,
+			})
+		}
+	}
+
+	r.targets[target].add(steps, failed)
+	r.result.Summary.add(steps, failed)
+}
+
+// keep reports whether to keep a request or monitor, given whether it failed
+// and how many are kept already.
+func (r *recorder) keep(failed bool, kept int) bool {
+	return !r.result.Sampled || (failed && kept < MaxSampledFailures)
+}
+
+// drop records an iteration of target that was due but never started.
+func (r *recorder) drop(target *SimulationTarget) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.targets[target].DroppedIterations++
+	r.result.Summary.DroppedIterations++
+}
+
+// monitorLatency returns the named monitor of a step, keeping monitors in the
+// order they first reported.
+func (r *recorder) monitorLatency(target string, step string, name string) *monitorLatency {
+	for _, m := range r.monitors {
+		if m.target == target && m.step == step && m.name == name {
+			return m
+		}
+	}
+
+	m := &monitorLatency{target: target, step: step, name: name, latency: histogram.New()}
+	r.monitors = append(r.monitors, m)
+
+	return m
+}
+
+func (r *recorder) finish(timedOut bool) *SimulationResult {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	elapsed := time.Since(r.result.StartedAt)
+	r.result.Duration = duration.Duration(elapsed)
+	r.result.TimedOut = timedOut
+
+	r.result.latency = histogram.New()
+	r.result.Latency = make([]*LatencyResult, 0, len(r.targetOrder)+len(r.monitors))
+	for _, target := range r.targetOrder {
+		r.result.latency.Merge(r.latencies[target])
+		r.result.Latency = append(r.result.Latency, newLatencyResult(LatencyKindTarget, target.name, "", r.latencies[target], elapsed))
+	}
+
+	for _, m := range r.monitors {
+		r.result.Latency = append(r.result.Latency, newLatencyResult(LatencyKindMonitor, m.name, m.target, m.latency, elapsed))
+
+		consistency := &ConsistencyResult{Target: m.target, Step: m.step, Name: m.name, Satisfied: m.satisfied, Max: duration.Duration(m.maxToSuccess)}
+		if m.satisfied > 0 {
+			consistency.Mean = duration.Duration(m.timeToSuccess / time.Duration(m.satisfied))
+		}
+		r.result.Consistency = append(r.result.Consistency, consistency)
+	}
+
+	return r.result
+}
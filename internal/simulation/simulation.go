@@ -1,154 +1,700 @@
 package simulation
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
 	"github.com/Easy-Infra-Ltd/easy-test/internal/assert"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/events"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/extract"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/feeder"
 	"github.com/Easy-Infra-Ltd/easy-test/internal/monitor"
 	"github.com/Easy-Infra-Ltd/easy-test/internal/threadpool"
 	"github.com/google/uuid"
 )
 
+// Variables set for every simulated request, so templates can tell clients,
+// attempts and the iterations of a client apart.
+const (
+	ClientVar    = "client"
+	AttemptVar   = "attempt"
+	IterationVar = "iteration"
+)
+
 type SimulationMonitorConfig struct {
 	name           string
 	monitorTargets []*monitor.MonitorTarget
 }
 
+func NewSimulationMonitorConfig(name string, monitorTargets []*monitor.MonitorTarget) *SimulationMonitorConfig {
+	assert.Assert(len(monitorTargets) > 0, "Simulation monitor must have at least one target")
+
+	return &SimulationMonitorConfig{
+		name:           name,
+		monitorTargets: monitorTargets,
+	}
+}
+
 type SimulationTarget struct {
-	id      uuid.UUID
-	clients []*api.Client
-	monitor *SimulationMonitorConfig
+	id        uuid.UUID
+	name      string
+	count     int
+	weight    int
+	steps     []*SimulationStep
+	thinkTime *ThinkTime
+}
+
+type SimulationTargetOption func(*SimulationTarget)
+
+// WithThinkTime makes the clients of the target wait between steps, see
+// ThinkTime.
+func WithThinkTime(thinkTime *ThinkTime) SimulationTargetOption {
+	return func(t *SimulationTarget) {
+		t.thinkTime = thinkTime
+	}
 }
 
-func NewSimulationTarget(clients []*api.Client, monitor *SimulationMonitorConfig) *SimulationTarget {
-	assert.Assert(len(clients) > 0, "Simulation Target can not have 0 or less clients")
+func NewSimulationTarget(name string, count int, weight int, steps []*SimulationStep, options ...SimulationTargetOption) *SimulationTarget {
+	assert.Assert(count > 0, "Simulation Target can not have 0 or less clients")
+	assert.Assert(weight >= 0, "Simulation Target weight can not be negative")
+	assert.Assert(len(steps) > 0, "Simulation Target must have at least one step")
+
+	t := &SimulationTarget{
+		id:     uuid.New(),
+		name:   name,
+		count:  count,
+		weight: weight,
+		steps:  steps,
+	}
 
-	return &SimulationTarget{
-		id:      uuid.New(),
-		clients: clients,
-		monitor: monitor,
+	for _, option := range options {
+		option(t)
 	}
+
+	assert.NoError(t.thinkTime.Validate(), "Simulation Target think time must be valid")
+
+	return t
+}
+
+func (t *SimulationTarget) Name() string {
+	return t.name
 }
 
+// SimulationTargetConfig describes what each simulated client does. Steps run
+// in order and share variables, so a value extracted by one step can be used
+// by the next. A target without steps is a single step built from Client,
+// Extract and Monitor, where either Client or Monitor can be left out for a
+// target that only sends requests or only monitors.
+//
+// Weight is the share of traffic the target receives when a simulation has
+// several targets, see SimulationConfig.
+//
+// Every client is a VirtualUser with its own cookies, connections and
+// variables, and ThinkTime sets how long it waits between steps.
 type SimulationTargetConfig struct {
-	Count   int                    `json:"count"`
-	Client  *api.ClientConfig      `json:"client"`
-	Monitor *monitor.MonitorConfig `json:"monitor"`
+	Name      string                  `json:"name,omitempty"`
+	Count     int                     `json:"count"`
+	Weight    int                     `json:"weight,omitempty"`
+	ThinkTime *ThinkTime              `json:"thinkTime,omitempty"`
+	Client    *api.ClientConfig       `json:"client,omitempty"`
+	Extract   extract.Rules           `json:"extract,omitempty"`
+	Monitor   *monitor.MonitorConfig  `json:"monitor,omitempty"`
+	Steps     []*SimulationStepConfig `json:"steps,omitempty"`
 }
 
+// StepConfigs returns the steps of the target, turning the single request
+// form into one step.
+func (c *SimulationTargetConfig) StepConfigs() []*SimulationStepConfig {
+	if len(c.Steps) > 0 {
+		return c.Steps
+	}
+
+	if c.Client == nil && c.Monitor == nil {
+		return nil
+	}
+
+	return []*SimulationStepConfig{{
+		Client:  c.Client,
+		Extract: c.Extract,
+		Monitor: c.Monitor,
+	}}
+}
+
+// Redacted returns a copy of the config with the secrets of its clients
+// replaced, see api.ClientConfig.Redacted.
+func (c *SimulationTargetConfig) Redacted() *SimulationTargetConfig {
+	if c == nil {
+		return nil
+	}
+
+	redacted := *c
+	redacted.Client = c.Client.Redacted()
+	redacted.Monitor = c.Monitor.Redacted()
+	if c.Steps != nil {
+		redacted.Steps = make([]*SimulationStepConfig, len(c.Steps))
+		for i, step := range c.Steps {
+			redacted.Steps[i] = step.Redacted()
+		}
+	}
+
+	return &redacted
+}
+
+// SimulationConfig describes a simulation against either a single Target or
+// a list of Targets. When no target has a weight every target runs Count
+// clients per attempt. When weights are set the clients of all targets are
+// pooled and shared out between the targets in proportion to their weights,
+// so targets with weights 70, 25 and 5 and a combined count of 100 get 70, 25
+// and 5 clients per attempt.
+//
+// Data names files of rows to feed to clients. Every client gets the next row
+// of each file under its name, so a row of "users" with an "email" column is
+// used in templates as {{.users.email}}.
+//
+// Tags label the simulation so a suite run can pick which simulations to run.
+//
+// Cadence is written as a duration such as "500ms" or "5s", or as a whole
+// number of seconds. Stages replace Attempts and Cadence with a load profile,
+// see Stage, and Arrival starts iterations at a rate rather than Cadence
+// apart.
+//
+// Duration replaces Attempts with a length of time. Every client keeps
+// iterating until it has passed, starting its iterations at least Cadence
+// apart, or with Arrival iterations keep starting at the rate, and iterations
+// still running then have GracePeriod to finish before they are cancelled.
 type SimulationConfig struct {
-	Name     string                 `json:"name"`
-	Target   SimulationTargetConfig `json:"target"`
-	Cadence  time.Duration          `json:"cadence"`
-	Attempts int                    `json:"attempts"`
+	Name        string                    `json:"name"`
+	Tags        []string                  `json:"tags,omitempty"`
+	Target      SimulationTargetConfig    `json:"target"`
+	Targets     []*SimulationTargetConfig `json:"targets,omitempty"`
+	Cadence     duration.OrSeconds        `json:"cadence"`
+	Attempts    int                       `json:"attempts"`
+	Duration    duration.Duration         `json:"duration,omitempty"`
+	GracePeriod duration.Duration         `json:"gracePeriod,omitempty"`
+	Stages      Stages                    `json:"stages,omitempty"`
+	Arrival     *Arrival                  `json:"arrival,omitempty"`
+	Thresholds  *Thresholds               `json:"thresholds,omitempty"`
+	Data        feeder.Sources            `json:"data,omitempty"`
 }
 
-func NewSimulationFromConfig(simConfig *SimulationConfig, dry bool) *Simulation {
-	// TODO: Allow for simulation to be created from config without Monitors
-	monitorTargets := monitor.CreateMonitorTargetsFromConfig(simConfig.Target.Monitor.MonitorTargets)
-	monitorConfig := &SimulationMonitorConfig{
-		name:           simConfig.Target.Monitor.Name,
-		monitorTargets: monitorTargets,
+// TargetConfigs returns the targets of the simulation, turning the single
+// target form into a list of one.
+func (c *SimulationConfig) TargetConfigs() []*SimulationTargetConfig {
+	if len(c.Targets) > 0 {
+		return c.Targets
 	}
 
-	clients := make([]*api.Client, 0, simConfig.Target.Count)
-	for i := 0; i < simConfig.Target.Count; i++ {
-		client := api.NewClient(api.NewClientParams(simConfig.Target.Client.Url, simConfig.Target.Client.ContentType, nil))
+	return []*SimulationTargetConfig{&c.Target}
+}
 
-		clients = append(clients, client)
+// ResolvePaths makes the relative paths of data files, body files and
+// certificates relative to dir rather than the working directory, for a
+// config read from a file in dir.
+func (c *SimulationConfig) ResolvePaths(dir string) {
+	c.Data.ResolvePaths(dir)
+	for _, target := range c.TargetConfigs() {
+		if target == nil {
+			continue
+		}
+
+		target.Client.ResolvePaths(dir)
+		target.Monitor.ResolvePaths(dir)
+		for _, step := range target.Steps {
+			if step != nil {
+				step.Client.ResolvePaths(dir)
+				step.Monitor.ResolvePaths(dir)
+			}
+		}
 	}
+}
 
-	simTarget := NewSimulationTarget(clients, monitorConfig)
-	return NewSimulation(simConfig.Name, simTarget, simConfig.Attempts, simConfig.Cadence*time.Second, dry)
+// Redacted returns a copy of the config with credentials, secret headers and
+// client keys replaced, safe to write to a report that gets shared.
+func (c *SimulationConfig) Redacted() *SimulationConfig {
+	if c == nil {
+		return nil
+	}
+
+	redacted := *c
+	redacted.Target = *c.Target.Redacted()
+	if c.Targets != nil {
+		redacted.Targets = make([]*SimulationTargetConfig, len(c.Targets))
+		for i, target := range c.Targets {
+			redacted.Targets[i] = target.Redacted()
+		}
+	}
+
+	return &redacted
+}
+
+// Concurrency returns how many clients the simulation keeps iterating at once
+// when each of them holds a worker for the whole run, as they do with
+// concurrency stages or a duration, or 0 when clients only hold a worker for
+// an iteration.
+func (c *SimulationConfig) Concurrency() int {
+	if c.Arrival != nil || c.Stages.ByRate() {
+		return 0
+	}
+
+	if c.Duration > 0 {
+		clients := 0
+		for _, target := range c.TargetConfigs() {
+			if target != nil {
+				clients += target.Count
+			}
+		}
+
+		return clients
+	}
+
+	return c.Stages.PeakTarget()
+}
+
+// DefaultGracePeriod is how long iterations still running at the end of a
+// duration-bound simulation have to finish when GracePeriod is not set.
+const DefaultGracePeriod = 30 * time.Second
+
+func (c *SimulationConfig) GracePeriodOrDefault() time.Duration {
+	if c.GracePeriod == 0 {
+		return DefaultGracePeriod
+	}
+
+	return c.GracePeriod.Std()
+}
+
+// RunTime returns how long the simulation runs for at most, before any
+// iterations are cut off, or 0 when that depends on the attempts.
+func (c *SimulationConfig) RunTime() time.Duration {
+	if c.Duration > 0 {
+		return c.Duration.Std() + c.GracePeriodOrDefault()
+	}
+
+	return c.Stages.Duration()
+}
+
+func NewSimulationFromConfig(simConfig *SimulationConfig, workers int, dry bool) (*Simulation, error) {
+	data, err := simConfig.Data.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load data: %w", err)
+	}
+
+	targetConfigs := simConfig.TargetConfigs()
+	targets := make([]*SimulationTarget, 0, len(targetConfigs))
+	for i, targetConfig := range targetConfigs {
+		stepConfigs := targetConfig.StepConfigs()
+		steps := make([]*SimulationStep, 0, len(stepConfigs))
+		for j, v := range stepConfigs {
+			steps = append(steps, NewSimulationStepFromConfig(v, j))
+		}
+
+		name := targetConfig.Name
+		if name == "" {
+			name = fmt.Sprintf("target %d", i+1)
+		}
+
+		targets = append(targets, NewSimulationTarget(name, targetConfig.Count, targetConfig.Weight, steps, WithThinkTime(targetConfig.ThinkTime)))
+	}
+
+	return NewSimulation(simConfig.Name, targets, simConfig.Attempts, simConfig.Cadence.Std(), workers, dry, WithData(data), WithStages(simConfig.Stages), WithArrival(simConfig.Arrival), withConfigDuration(simConfig)), nil
 }
 
 type Simulation struct {
 	id       uuid.UUID
 	name     string
-	target   *SimulationTarget
+	targets  []*SimulationTarget
 	attempts int
 	cadence  time.Duration
+	workers  int
 	logger   *slog.Logger
 	dry      bool
+	data     map[string]*feeder.Feeder
+	stages   Stages
+	arrival  *Arrival
+	duration time.Duration
+	grace    time.Duration
 }
 
-func NewSimulation(name string, target *SimulationTarget, attempts int, cadence time.Duration, dry bool) *Simulation {
-	assert.NotNil(target, "Simulation target can not be nil when creating a Simulation")
-	assert.Assert(attempts > 0, "Must have at least 1 attempts")
+type SimulationOption func(*Simulation)
+
+// WithData feeds every client the next row of each feeder, stored in its vars
+// under the feeder's name.
+func WithData(data map[string]*feeder.Feeder) SimulationOption {
+	return func(s *Simulation) {
+		s.data = data
+	}
+}
+
+// WithStages runs the simulation through a load profile instead of attempts
+// cadence apart.
+func WithStages(stages Stages) SimulationOption {
+	return func(s *Simulation) {
+		s.stages = stages
+	}
+}
+
+// WithArrival starts iterations at a rate rather than a whole attempt at a
+// time, see Arrival.
+func WithArrival(arrival *Arrival) SimulationOption {
+	return func(s *Simulation) {
+		s.arrival = arrival
+	}
+}
+
+// WithDuration runs the simulation for d rather than a number of attempts,
+// giving iterations still running at the end grace to finish before they are
+// cancelled.
+func WithDuration(d time.Duration, grace time.Duration) SimulationOption {
+	return func(s *Simulation) {
+		s.duration = d
+		s.grace = grace
+	}
+}
+
+func withConfigDuration(config *SimulationConfig) SimulationOption {
+	if config.Duration == 0 {
+		return func(*Simulation) {}
+	}
+
+	return WithDuration(config.Duration.Std(), config.GracePeriodOrDefault())
+}
+
+// NewSimulation creates a Simulation that runs attempts rounds of requests
+// against targets, cadence apart. At most workers clients run at once.
+func NewSimulation(name string, targets []*SimulationTarget, attempts int, cadence time.Duration, workers int, dry bool, options ...SimulationOption) *Simulation {
+	assert.Assert(len(targets) > 0, "Simulation must have at least one target")
+	assert.Assert(workers > 0, "Simulation must have at least 1 worker")
+
+	weighted := 0
+	for _, target := range targets {
+		assert.NotNil(target, "Simulation target can not be nil when creating a Simulation")
+		if target.weight > 0 {
+			weighted++
+		}
+	}
+	assert.Assert(weighted == 0 || weighted == len(targets), "Either every Simulation target must have a weight or none of them")
 
 	id := uuid.New()
 	logger := slog.Default().With("area", fmt.Sprintf("Simulation %s %s", id.String(), name))
 
 	logger.Info("Creating new simulation")
 
-	return &Simulation{
+	s := &Simulation{
 		id:       id,
 		name:     name,
-		target:   target,
+		targets:  targets,
 		attempts: attempts,
 		cadence:  cadence,
+		workers:  workers,
 		logger:   logger,
 		dry:      dry,
 	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	assert.Assert(attempts > 0 || len(s.stages) > 0 || s.duration > 0, "Must have at least 1 attempts")
+	assert.Assert(s.duration == 0 || len(s.stages) == 0, "Simulation duration can not be combined with stages")
+	assert.Assert(s.duration >= 0 && s.grace >= 0, "Simulation duration and grace period can not be negative")
+	assert.NoError(s.stages.Validate(), "Simulation stages must be valid")
+	assert.NoError(s.arrival.Validate(), "Simulation arrival must be valid")
+	assert.Assert(s.arrival == nil || len(s.stages) > 0 || s.arrival.Rate > 0, "Simulation arrival must have a rate without stages")
+	assert.Assert(s.arrival == nil || len(s.stages) == 0 || s.stages.ByRate(), "Simulation arrival needs rate stages")
+	assert.Assert(s.stages.PeakTarget() <= s.stageWorkers(), "Simulation stages can not target more clients than there are workers")
+	assert.Assert(s.duration == 0 || s.arrivals() || len(s.schedule()) <= s.stageWorkers(), "Simulation duration can not run more clients than there are workers")
+
+	return s
+}
+
+// nextRows adds the next row of every feeder in data to vars. A unique
+// feeder that has run out of rows returns feeder.ErrExhausted.
+func nextRows(data map[string]*feeder.Feeder, vars map[string]any) error {
+	for _, name := range slices.Sorted(maps.Keys(data)) {
+		row, err := data[name].Next()
+		if err != nil {
+			return fmt.Errorf("data %q: %w", name, err)
+		}
+
+		vars[name] = row
+	}
+
+	return nil
 }
 
-func (s *Simulation) Start() []*api.Client {
+// schedule returns the target of every client in a single attempt.
+func (s *Simulation) schedule() []*SimulationTarget {
+	total := 0
+	totalWeight := 0
+	for _, target := range s.targets {
+		total += target.count
+		totalWeight += target.weight
+	}
+
+	schedule := make([]*SimulationTarget, 0, total)
+	if totalWeight == 0 {
+		for _, target := range s.targets {
+			for range target.count {
+				schedule = append(schedule, target)
+			}
+		}
+
+		return schedule
+	}
+
+	// Smooth weighted round robin spreads each target evenly through the
+	// attempt rather than sending all of one target's requests together.
+	current := make([]int, len(s.targets))
+	for range total {
+		best := 0
+		for i, target := range s.targets {
+			current[i] += target.weight
+			if current[i] > current[best] {
+				best = i
+			}
+		}
+
+		current[best] -= totalWeight
+		schedule = append(schedule, s.targets[best])
+	}
+
+	return schedule
+}
+
+// Start runs the simulation until every attempt or every stage has finished,
+// or its duration and grace period have passed, or ctx is done.
+// Cancelling ctx stops scheduling new clients, cancels requests in flight and
+// stops any monitors, and the result only covers what ran before then. A dry
+// run sends nothing, use Plan to see what it would send.
+func (s *Simulation) Start(ctx context.Context) *SimulationResult {
 	assert.NotNil(s, "Simulation can not be nil when calling start on it")
-	assert.NotNil(s.target, "SimulationTarget can not be nill when calling start on a Simulation")
-	assert.Assert(len(s.target.clients) > 0, "When calling Simulation Start the target for the Simulation must have at least one client")
+	assert.NotNil(ctx, "Context can not be nil when calling start on a Simulation")
+	assert.Assert(len(s.targets) > 0, "When calling Simulation Start the Simulation must have at least one target")
+
+	recorder := newRecorder(s.name, s.targets, s.duration > 0)
+	if s.dry {
+		s.logger.Info("Dry run, not sending any requests")
+		return recorder.finish(false)
+	}
 
 	s.logger.Info("Starting Simulation")
-	tp := threadpool.NewThreadPool(1, 10, 5*time.Second)
+	emitter := events.FromContext(ctx).WithSimulation(s.id.String(), s.name)
+	emitter.Emit(events.SimulationStart, map[string]any{
+		"attempts": s.attempts,
+		"cadence":  s.cadence.String(),
+		"stages":   s.stages,
+		"arrival":  s.arrival,
+		"duration": s.duration.String(),
+		"workers":  s.workers,
+		"targets":  len(s.targets),
+	})
+
+	// Clients of concurrency stages keep their worker for as long as they
+	// iterate, so every worker has to be running from the start.
+	minWorkers, maxWorkers := 1, min(s.workers, threadpool.MAX_WORKERS)
+	if s.arrivals() {
+		maxWorkers = s.maxInFlight()
+	} else if len(s.stages) > 0 || s.duration > 0 {
+		minWorkers = s.stageWorkers()
+	}
+
+	// Iterations run on their own context, so the ones still running once the
+	// grace period of a duration-bound run is over can be cancelled.
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tp := threadpool.NewThreadPool(minWorkers, maxWorkers, 5*time.Second)
 	tp.Run()
+	defer tp.Stop()
 
-	s.logger.Info("ThreadPool Initialised, executing attempts")
-	for i := 0; i < s.attempts; i++ {
-		for _, v := range s.target.clients {
-			s.logger.Info("Adding new simulation task to ThreadPool")
-			tp.Add(NewSimulationTask(s.name+" "+s.id.String(), func() string {
-				// TODO: Make this execute some Lua Script
-				resp, err := v.Post()
-				if err != nil {
-					s.logger.Error(err.Error())
-					return ""
-				}
+	// Every client keeps its user across attempts and stages. An arrival is
+	// a new user each time, so its user is let go after one iteration.
+	users := newVirtualUsers()
+	defer users.close()
 
-				assert.NotNil(resp, "Response from Post can not be nil")
-				assert.NotNil(resp.Body, "Response Body can not be nil")
+	newTask := func(target *SimulationTarget, attempt int, client int) *SimulationTask {
+		vars := map[string]any{
+			ClientVar:  client,
+			AttemptVar: attempt,
+		}
 
-				var id string
+		if err := nextRows(s.data, vars); err != nil {
+			s.logger.Warn("Ran out of data, not scheduling any more clients", "error", err)
+			return nil
+		}
 
-				// TODO: Extract the ID
-				return id
-			}, s.target.monitor))
+		user := users.get(target, client)
+		record := func(steps []*StepResult, failed bool) {
+			recorder.record(target, attempt, client, steps, failed)
+			if s.arrivals() {
+				users.release(user)
+			}
 		}
 
-		time.Sleep(s.cadence)
+		name := s.name + " " + s.id.String() + " " + target.name
+		taskCtx := events.NewContext(runCtx, emitter.WithTask(name, map[string]any{
+			"target":  target.name,
+			"attempt": attempt,
+			"client":  client,
+		}))
+
+		return NewSimulationTask(taskCtx, name, user, vars, s.workers, record)
+	}
+
+	drop := func(target *SimulationTarget, attempt int, client int) {
+		recorder.drop(target)
+		emitter.Emit(events.IterationDropped, map[string]any{
+			"target":  target.name,
+			"attempt": attempt,
+			"client":  client,
+		})
+	}
+
+	switch {
+	case s.arrivals():
+		s.logger.Info("ThreadPool Initialised, executing arrivals")
+		s.runArrivals(runCtx, tp, newTask, drop)
+	case len(s.stages) > 0:
+		s.logger.Info("ThreadPool Initialised, executing stages")
+		s.runClientStages(runCtx, tp, s.stages, 0, newTask)
+	case s.duration > 0:
+		s.logger.Info("ThreadPool Initialised, executing clients", "duration", s.duration)
+		s.runClientStages(runCtx, tp, s.durationStages(), s.cadence, newTask)
+	default:
+		s.logger.Info("ThreadPool Initialised, executing attempts")
+		s.runAttempts(runCtx, tp, newTask)
+	}
+
+	s.drain(tp, cancel)
+
+	timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+	if timedOut {
+		s.logger.Warn("Simulation timed out before every attempt finished")
+	}
+
+	result := recorder.finish(timedOut)
+	for _, target := range result.Targets {
+		s.logger.Info("Simulation target finished", "target", target.Name, "iterations", target.Iterations, "failedIterations", target.FailedIterations, "droppedIterations", target.DroppedIterations, "requests", target.Requests, "failedRequests", target.FailedRequests)
+	}
+
+	if dropped := result.Summary.DroppedIterations; dropped > 0 {
+		s.logger.Warn("Workers could not keep up with the arrival rate, dropped iterations", "droppedIterations", dropped, "maxInFlight", s.maxInFlight())
+	}
+
+	emitter.Emit(events.SimulationEnd, map[string]any{
+		"duration":          result.Duration.String(),
+		"timedOut":          result.TimedOut,
+		"iterations":        result.Summary.Iterations,
+		"requests":          result.Summary.Requests,
+		"failedRequests":    result.Summary.FailedRequests,
+		"droppedIterations": result.Summary.DroppedIterations,
+		"monitors":          result.Summary.Monitors,
+		"failedMonitors":    result.Summary.FailedMonitors,
+	})
+
+	return result
+}
+
+// durationStages returns stages that keep every client of an attempt
+// iterating for the duration of the simulation.
+func (s *Simulation) durationStages() Stages {
+	clients := len(s.schedule())
+	return Stages{
+		{Target: clients},
+		{Duration: duration.Duration(s.duration), Target: clients},
 	}
+}
 
-	tp.Wait()
+// drain waits for the iterations still running once nothing new is started.
+// Those of a duration-bound simulation are cancelled once the grace period
+// has passed.
+func (s *Simulation) drain(tp *threadpool.ThreadPool, cancel context.CancelFunc) {
+	if s.duration == 0 {
+		tp.Wait()
+		return
+	}
 
-	return s.target.clients
+	done := make(chan struct{})
+	go func() {
+		tp.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(s.grace)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		s.logger.Warn("Iterations still running after the grace period, cancelling them", "gracePeriod", s.grace)
+		cancel()
+		<-done
+	}
 }
 
-type SimulationTaskFunc func() string
+// runAttempts starts every client of an attempt, attempts cadence apart,
+// until every attempt has started or ctx is done.
+func (s *Simulation) runAttempts(ctx context.Context, tp *threadpool.ThreadPool, newTask newTaskFunc) {
+	for i := 0; i < s.attempts; i++ {
+		clients := make(map[*SimulationTarget]int, len(s.targets))
+		for _, target := range s.schedule() {
+			if ctx.Err() != nil {
+				return
+			}
+
+			task := newTask(target, i, clients[target])
+			if task == nil {
+				return
+			}
+			clients[target]++
+
+			s.logger.Info("Adding new simulation task to ThreadPool", "target", target.name)
+			tp.Add(task)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.cadence):
+		}
+	}
+}
 
+// SimulationRecordFunc receives the results of the steps a SimulationTask
+// ran, and whether a failed step stopped the remaining steps.
+type SimulationRecordFunc func(steps []*StepResult, failed bool)
+
+// SimulationTask runs every step of a target once, as one iteration of a
+// virtual user. The steps share the user's variables, with vars added, which
+// start with the client and attempt numbers.
 type SimulationTask struct {
 	name    string
-	task    SimulationTaskFunc
-	monitor *SimulationMonitorConfig
+	user    *VirtualUser
+	vars    map[string]any
+	workers int
+	record  SimulationRecordFunc
+	ctx     context.Context
 	logger  *slog.Logger
 }
 
-func NewSimulationTask(name string, task SimulationTaskFunc, monitor *SimulationMonitorConfig) *SimulationTask {
+func NewSimulationTask(ctx context.Context, name string, user *VirtualUser, vars map[string]any, workers int, record SimulationRecordFunc) *SimulationTask {
+	assert.NotNil(user, "Simulation task user can not be nil")
+	assert.NotNil(record, "Simulation task record func can not be nil")
+
 	logger := slog.Default().With("area", "SimulationTask "+name)
 	return &SimulationTask{
 		name:    name,
-		task:    task,
-		monitor: monitor,
+		user:    user,
+		vars:    vars,
+		workers: workers,
+		record:  record,
+		ctx:     ctx,
 		logger:  logger,
 	}
 }
@@ -158,17 +704,31 @@ func (t SimulationTask) GetName() string {
 }
 
 func (t *SimulationTask) Run() {
-	id := t.task()
-	monitor := t.CreateMonitor(id)
+	if t.ctx.Err() != nil {
+		t.logger.Info("Simulation finished before task started, skipping")
+		return
+	}
 
-	monitor.Start()
-}
+	vars := t.user.begin(t.vars)
+	defer t.user.end()
 
-func (t *SimulationTask) CreateMonitor(id string) *monitor.Monitor {
-	if t.monitor == nil {
-		t.logger.Info("No monitors configured for SimulationTask")
-		return nil
+	target := t.user.target
+	results := make([]*StepResult, 0, len(target.steps))
+	for i, step := range target.steps {
+		if i > 0 {
+			target.thinkTime.wait(t.ctx)
+		}
+
+		// TODO: Make this execute some Lua Script
+		result, err := step.Run(t.ctx, t.user.session, vars, t.workers)
+		results = append(results, result)
+
+		if err != nil {
+			t.logger.Error(fmt.Sprintf("Step %s failed, skipping remaining steps: %s", step.Name(), err.Error()))
+			t.record(results, true)
+			return
+		}
 	}
 
-	return monitor.NewMonitor(t.monitor.name, t.monitor.monitorTargets)
+	t.record(results, false)
 }
@@ -1,21 +1,37 @@
 package simulation_test
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/events"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/extract"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/feeder"
 	"github.com/Easy-Infra-Ltd/easy-test/internal/logger"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/monitor"
 	"github.com/Easy-Infra-Ltd/easy-test/internal/simulation"
 )
 
 type SimulationTestParam struct {
 	name     string
-	clients  []*api.Client
+	count    int
+	steps    []*simulation.SimulationStep
 	attempts int
 	cadence  time.Duration
 }
@@ -29,22 +45,21 @@ func TestSimulation(t *testing.T) {
 	logger = logger.With("area", "Simulation Test").With("process", "test")
 	slog.SetDefault(logger)
 
-	server := api.NewServer("Test Server", ":3333")
-	server.AddRoute("POST /test", ServerHandlerTesting)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /test", ServerHandlerTesting)
 
-	go server.Start()
+	server := httptest.NewServer(mux)
+	defer server.Close()
 
-	clients := make([]*api.Client, 0, 10)
-	for i := 0; i < 10; i++ {
-		jsonData, _ := json.Marshal(`{"test": "value"}`)
-
-		clients = append(clients, api.NewClient(api.NewClientParams("http://localhost:3333/test", "application/json", bytes.NewBuffer(jsonData))))
-	}
+	body := api.NewBody(json.RawMessage(`{"test": "value"}`))
+	client := api.NewClient(api.NewClientParams(http.MethodPost, server.URL+"/test", "application/json", body))
+	steps := []*simulation.SimulationStep{simulation.NewSimulationStep("", client, nil, nil, nil)}
 
 	simulationTests := []SimulationTestParam{
 		{
 			name:     "3 attempts every 5 seconds",
-			clients:  clients,
+			count:    10,
+			steps:    steps,
 			attempts: 3,
 			cadence:  5 * time.Second,
 		},
@@ -53,10 +68,1226 @@ func TestSimulation(t *testing.T) {
 	t.Parallel()
 	for _, v := range simulationTests {
 		t.Run(v.name, func(t *testing.T) {
-			target := simulation.NewSimulationTarget(v.clients)
-			sim := simulation.NewSimulation("Test Simulation", target, v.attempts, v.cadence)
+			target := simulation.NewSimulationTarget("test", v.count, 0, v.steps)
+			sim := simulation.NewSimulation("Test Simulation", []*simulation.SimulationTarget{target}, v.attempts, v.cadence, 10, false)
+
+			sim.Start(t.Context())
+		})
+	}
+}
+
+func TestSimulationUniquePayloads(t *testing.T) {
+	received := make(chan map[string]any, 6)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /orders", func(res http.ResponseWriter, req *http.Request) {
+		var payload map[string]any
+		json.NewDecoder(req.Body).Decode(&payload)
+		received <- payload
+
+		res.WriteHeader(http.StatusCreated)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	body := api.NewBody(json.RawMessage(`{"id": "{{uuid}}", "client": "{{.client}}", "attempt": "{{.attempt}}"}`))
+
+	client := api.NewClient(api.NewClientParams(http.MethodPost, server.URL+"/orders", "application/json", body))
+	steps := []*simulation.SimulationStep{simulation.NewSimulationStep("", client, nil, nil, nil)}
+
+	sim := simulation.NewSimulation("Unique Payloads", []*simulation.SimulationTarget{simulation.NewSimulationTarget("orders", 3, 0, steps)}, 2, 10*time.Millisecond, 10, false)
+	sim.Start(t.Context())
+	close(received)
+
+	ids := map[string]bool{}
+	slots := map[string]bool{}
+	for payload := range received {
+		ids[payload["id"].(string)] = true
+		slots[fmt.Sprintf("%v/%v", payload["client"], payload["attempt"])] = true
+	}
+
+	if len(ids) != 6 {
+		t.Errorf("received %d unique ids, want 6", len(ids))
+	}
+	if len(slots) != 6 {
+		t.Errorf("received %d unique client and attempt pairs, want 6", len(slots))
+	}
+}
+
+func TestSimulationSteps(t *testing.T) {
+	var mu sync.Mutex
+	fetched := map[string]int{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(res http.ResponseWriter, req *http.Request) {
+		var payload map[string]any
+		json.NewDecoder(req.Body).Decode(&payload)
+
+		res.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(res, `{"token": "token-%v"}`, payload["user"])
+	})
+	mux.HandleFunc("POST /orders", func(res http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.Header.Get("Authorization"), "Bearer token-") {
+			res.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(res, `{"id": "%s", "status": "open"}`, strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+	})
+	mux.HandleFunc("GET /orders/{id}", func(res http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		fetched[req.PathValue("id")]++
+		mu.Unlock()
+	})
+	mux.HandleFunc("POST /fail", func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusInternalServerError)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	login := api.NewClient(api.NewClientParams(http.MethodPost, server.URL+"/login", "application/json", api.NewBody(json.RawMessage(`{"user": "{{.client}}"}`))))
+	create, err := api.NewClientParamsFromConfig(&api.ClientConfig{
+		Url:  server.URL + "/orders",
+		Auth: &api.AuthConfig{Type: "bearer", Token: "{{.token}}"},
+	}, http.MethodPost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+"/orders/{{.id}}", "", nil))
+
+	steps := []*simulation.SimulationStep{
+		simulation.NewSimulationStep("login", login, extract.Rules{"token": {JSONPath: "$.token"}}, &simulation.Assertions{Status: []int{http.StatusOK}}, nil),
+		simulation.NewSimulationStep("create", api.NewClient(create), extract.Rules{"id": {JSONPath: "$.id"}}, &simulation.Assertions{
+			Status: []int{http.StatusCreated},
+			Body:   map[string]any{"id": "{{.token}}", "status": "open"},
+		}, nil),
+		simulation.NewSimulationStep("get", get, nil, nil, nil),
+	}
+
+	simulation.NewSimulation("Steps", []*simulation.SimulationTarget{simulation.NewSimulationTarget("orders", 2, 0, steps)}, 1, 0, 10, false).Start(t.Context())
+
+	want := map[string]int{"token-0": 1, "token-1": 1}
+	if !reflect.DeepEqual(fetched, want) {
+		t.Errorf("fetched orders %v, want %v", fetched, want)
+	}
+
+	failing := []*simulation.SimulationStep{
+		simulation.NewSimulationStep("fail", api.NewClient(api.NewClientParams(http.MethodPost, server.URL+"/fail", "", nil)), nil, &simulation.Assertions{Status: []int{http.StatusOK}}, nil),
+		simulation.NewSimulationStep("get", get, nil, nil, nil),
+	}
+
+	simulation.NewSimulation("Failing Steps", []*simulation.SimulationTarget{simulation.NewSimulationTarget("failing", 1, 0, failing)}, 1, 0, 10, false).Start(t.Context())
+
+	if len(fetched) != 2 {
+		t.Errorf("steps after a failed assertion ran, fetched orders %v", fetched)
+	}
+}
+
+func TestSimulationWeightedTargets(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string]int{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{path}", func(res http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		received[req.Method+" "+req.PathValue("path")]++
+		mu.Unlock()
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	newTarget := func(name, method string, count, weight int) *simulation.SimulationTarget {
+		client := api.NewClient(api.NewClientParams(method, server.URL+"/"+name, "", nil))
+		return simulation.NewSimulationTarget(name, count, weight, []*simulation.SimulationStep{
+			simulation.NewSimulationStep("", client, nil, nil, nil),
+		})
+	}
+
+	tests := []struct {
+		name     string
+		targets  []*simulation.SimulationTarget
+		expected map[string]int
+	}{
+		{
+			name: "Counts",
+			targets: []*simulation.SimulationTarget{
+				newTarget("reads", http.MethodGet, 3, 0),
+				newTarget("writes", http.MethodPost, 2, 0),
+			},
+			expected: map[string]int{"GET reads": 6, "POST writes": 4},
+		},
+		{
+			name: "Weights",
+			targets: []*simulation.SimulationTarget{
+				newTarget("reads", http.MethodGet, 1, 70),
+				newTarget("writes", http.MethodPost, 1, 25),
+				newTarget("deletes", http.MethodDelete, 18, 5),
+			},
+			expected: map[string]int{"GET reads": 28, "POST writes": 10, "DELETE deletes": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			clear(received)
+			mu.Unlock()
+
+			simulation.NewSimulation("Weighted", tt.targets, 2, 0, 10, false).Start(t.Context())
+
+			if !reflect.DeepEqual(received, tt.expected) {
+				t.Errorf("received %v, want %v", received, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSimulationTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := api.NewClient(api.NewClientParams(http.MethodGet, server.URL, "", nil))
+	target := simulation.NewSimulationTarget("slow", 4, 0, []*simulation.SimulationStep{
+		simulation.NewSimulationStep("", client, nil, nil, nil),
+	})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result := simulation.NewSimulation("Timeout", []*simulation.SimulationTarget{target}, 3, time.Second, 2, false).Start(ctx)
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("simulation ran for %s after its deadline", elapsed)
+	}
+	if !result.TimedOut {
+		t.Error("result is not marked as timed out")
+	}
+	if got := result.Targets[0]; got.Iterations == 0 || got.Iterations >= 12 || got.FailedIterations != got.Iterations {
+		t.Errorf("target result = %+v, want a partial run where every iteration failed", got)
+	}
+}
+
+func TestSimulationPlan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		t.Errorf("dry run sent %s %s", req.Method, req.URL)
+	}))
+	defer server.Close()
+
+	login := api.NewClient(api.NewClientParams(http.MethodPost, server.URL+"/login", "application/json", api.NewBody(json.RawMessage(`{"user": "{{.client}}"}`))))
+	get := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+"/orders/{{.id}}", "", nil))
+	poll := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+"/orders/{{.id}}/status", "", nil))
+
+	steps := []*simulation.SimulationStep{
+		simulation.NewSimulationStep("login", login, extract.Rules{"id": {JSONPath: "$.id"}}, nil, nil),
+		simulation.NewSimulationStep("get", get, nil, nil, simulation.NewSimulationMonitorConfig("status", []*monitor.MonitorTarget{
+			monitor.NewMonitorTarget(poll, map[string]any{"id": "{{.id}}"}, time.Second, 3),
+		})),
+	}
+
+	sim := simulation.NewSimulation("Plan", []*simulation.SimulationTarget{simulation.NewSimulationTarget("orders", 2, 0, steps)}, 2, 5*time.Second, 10, true)
+
+	result := sim.Start(t.Context())
+	if result.Targets[0].Iterations != 0 {
+		t.Errorf("dry run ran %d iterations", result.Targets[0].Iterations)
+	}
+
+	plan := sim.Plan()
+	if len(plan.Clients) != 4 {
+		t.Fatalf("plan has %d clients, want 4", len(plan.Clients))
+	}
+
+	last := plan.Clients[3]
+	if last.Offset.Std() != 5*time.Second || last.Attempt != 1 || last.Client != 1 {
+		t.Errorf("last client = %+v, want client 1 of attempt 1 at 5s", last)
+	}
+
+	if body := last.Steps[0].Request.Body; body != `{"user":1}` {
+		t.Errorf("login body = %s, want {\"user\":1}", body)
+	}
+
+	get0 := last.Steps[1]
+	if get0.Error != "" || get0.Request.Url != server.URL+"/orders/<id>" {
+		t.Errorf("get step = %+v, want a request to /orders/<id>", get0)
+	}
+
+	target := get0.Monitor.Targets[0]
+	if target.Duration.Std() != 3*time.Second || target.Request.Url != server.URL+"/orders/<id>/status" {
+		t.Errorf("monitor target = %+v, want 3 polls of /orders/<id>/status over 3s", target)
+	}
+
+	var text strings.Builder
+	if err := plan.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "+5s attempt 1, client 1 of target \"orders\"") {
+		t.Errorf("text plan is missing the last client:\n%s", text.String())
+	}
+}
+
+func TestSimulationResult(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /orders", func(res http.ResponseWriter, req *http.Request) {
+		var payload map[string]any
+		json.NewDecoder(req.Body).Decode(&payload)
+
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(res, `{"id": "order-%v"}`, payload["client"])
+	})
+	mux.HandleFunc("GET /orders/{id}/status", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(res, `{"id": "%s", "status": "shipped"}`, req.PathValue("id"))
+	})
+	mux.HandleFunc("GET /missing", func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusNotFound)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	create := api.NewClient(api.NewClientParams(http.MethodPost, server.URL+"/orders", "application/json", api.NewBody(json.RawMessage(`{"client": "{{.client}}"}`))))
+	status := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+"/orders/{{.id}}/status", "", nil))
+	missing := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+"/missing", "", nil))
+
+	orders := simulation.NewSimulationTarget("orders", 2, 0, []*simulation.SimulationStep{
+		simulation.NewSimulationStep("create", create, extract.Rules{"id": {JSONPath: "$.id"}}, nil, simulation.NewSimulationMonitorConfig("status", []*monitor.MonitorTarget{
+			monitor.NewMonitorTarget(status, map[string]any{"id": "{{.id}}", "status": "shipped"}, 10*time.Millisecond, 2),
+		})),
+	})
+	missingTarget := simulation.NewSimulationTarget("missing", 1, 0, []*simulation.SimulationStep{
+		simulation.NewSimulationStep("get", missing, nil, nil, nil),
+	})
+
+	result := simulation.NewSimulation("Result", []*simulation.SimulationTarget{orders, missingTarget}, 1, 0, 10, false).Start(t.Context())
+
+	want := simulation.Summary{
+		Iterations:        3,
+		Requests:          3,
+		FailedRequests:    1,
+		Monitors:          2,
+		SatisfiedMonitors: 2,
+		Bytes:             result.Summary.Bytes,
+	}
+	if result.Summary != want {
+		t.Errorf("summary = %+v, want %+v", result.Summary, want)
+	}
+	if result.Targets[0].Requests != 2 || result.Targets[1].FailedRequests != 1 {
+		t.Errorf("target results = %+v, %+v", result.Targets[0], result.Targets[1])
+	}
+
+	for _, request := range result.Requests {
+		switch request.Target {
+		case "orders":
+			if request.StatusCode != http.StatusCreated || request.Failed || request.Bytes == 0 || request.Latency <= 0 {
+				t.Errorf("orders request = %+v", request)
+			}
+			if request.Extracted["id"] != fmt.Sprintf("order-%d", request.Client) {
+				t.Errorf("orders request extracted %v for client %d", request.Extracted, request.Client)
+			}
+		case "missing":
+			if request.StatusCode != http.StatusNotFound || !request.Failed {
+				t.Errorf("missing request = %+v, want a failed 404", request)
+			}
+		}
+	}
+
+	for _, m := range result.Monitors {
+		if !m.Satisfied() || m.Polls != 1 || m.TimeToSuccess <= 0 || m.Url != fmt.Sprintf("%s/orders/order-%d/status", server.URL, m.Client) {
+			t.Errorf("monitor result = %+v", m)
+		}
+	}
+
+	wantLatency := []struct {
+		kind  string
+		name  string
+		count int64
+	}{
+		{kind: simulation.LatencyKindTarget, name: "orders", count: 2},
+		{kind: simulation.LatencyKindTarget, name: "missing", count: 1},
+		{kind: simulation.LatencyKindMonitor, name: "status", count: 2},
+	}
+	if len(result.Latency) != len(wantLatency) {
+		t.Fatalf("got %d latency results, want %d", len(result.Latency), len(wantLatency))
+	}
+	for i, want := range wantLatency {
+		l := result.Latency[i]
+		if l.Kind != want.kind || l.Name != want.name || l.Count != want.count {
+			t.Errorf("latency[%d] = %+v, want %s %s with %d samples", i, l, want.kind, want.name, want.count)
+		}
+		if l.Min <= 0 || l.Min > l.P50 || l.P50 > l.P99 || l.P99 > l.Max || l.Throughput <= 0 {
+			t.Errorf("latency[%d] statistics are inconsistent: %+v", i, l)
+		}
+	}
+
+	// Thresholds are read from the same histograms as the summary table.
+	slowest := max(result.Latency[0].Max, result.Latency[1].Max)
+	if !result.CheckThresholds(&simulation.Thresholds{Latency: map[string]duration.Duration{"p100": slowest}}) {
+		t.Errorf("p100 threshold of %s breached: %v", slowest, result.Thresholds)
+	}
+
+	var sb strings.Builder
+	if err := result.WriteSummary(&sb); err != nil {
+		t.Fatalf("WriteSummary() error: %v", err)
+	}
+	for _, want := range []string{"3 request(s), 1 failed", "P95", "orders", "missing", "orders/status"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("summary does not contain %q:\n%s", want, sb.String())
+		}
+	}
+}
+
+func TestSimulationThresholds(t *testing.T) {
+	result := &simulation.SimulationResult{
+		Summary: simulation.Summary{Requests: 4, FailedRequests: 1, Monitors: 2, SatisfiedMonitors: 1, FailedMonitors: 1},
+	}
+	for _, latency := range []time.Duration{10, 20, 30, 100} {
+		result.Requests = append(result.Requests, &simulation.RequestResult{Latency: duration.Duration(latency * time.Millisecond)})
+	}
+
+	quarter := 0.25
+	tenth := 0.1
+
+	tests := []struct {
+		name       string
+		thresholds *simulation.Thresholds
+		passed     []bool
+	}{
+		{
+			name:       "No thresholds",
+			thresholds: nil,
+			passed:     nil,
+		},
+		{
+			name:       "Error rate within limit",
+			thresholds: &simulation.Thresholds{MaxErrorRate: &quarter},
+			passed:     []bool{true},
+		},
+		{
+			name:       "Error rate breached",
+			thresholds: &simulation.Thresholds{MaxErrorRate: &tenth},
+			passed:     []bool{false},
+		},
+		{
+			name: "Latency",
+			thresholds: &simulation.Thresholds{Latency: map[string]duration.Duration{
+				"max":  duration.Duration(50 * time.Millisecond),
+				"mean": duration.Duration(40 * time.Millisecond),
+				"p50":  duration.Duration(21 * time.Millisecond),
+				"p75":  duration.Duration(25 * time.Millisecond),
+			}},
+			passed: []bool{false, true, true, false},
+		},
+		{
+			name:       "Monitors satisfied",
+			thresholds: &simulation.Thresholds{MonitorsSatisfied: true},
+			passed:     []bool{false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := !slices.Contains(tt.passed, false)
+			if got := result.CheckThresholds(tt.thresholds); got != want {
+				t.Errorf("CheckThresholds() = %v, want %v", got, want)
+			}
+
+			if len(result.Thresholds) != len(tt.passed) {
+				t.Fatalf("got %d threshold results, want %d", len(result.Thresholds), len(tt.passed))
+			}
+
+			for i, threshold := range result.Thresholds {
+				if threshold.Passed != tt.passed[i] {
+					t.Errorf("threshold %s passed = %v, want %v", threshold, threshold.Passed, tt.passed[i])
+				}
+			}
+		})
+	}
+}
+
+func TestThresholdsValidate(t *testing.T) {
+	negative := -0.1
+
+	tests := []struct {
+		name       string
+		thresholds *simulation.Thresholds
+		valid      bool
+	}{
+		{name: "Nil", thresholds: nil, valid: true},
+		{name: "Valid", thresholds: &simulation.Thresholds{Latency: map[string]duration.Duration{"p99.9": duration.Duration(time.Second), "mean": duration.Duration(time.Second)}}, valid: true},
+		{name: "Negative error rate", thresholds: &simulation.Thresholds{MaxErrorRate: &negative}, valid: false},
+		{name: "Unknown statistic", thresholds: &simulation.Thresholds{Latency: map[string]duration.Duration{"median": duration.Duration(time.Second)}}, valid: false},
+		{name: "Percentile above 100", thresholds: &simulation.Thresholds{Latency: map[string]duration.Duration{"p101": duration.Duration(time.Second)}}, valid: false},
+		{name: "Zero limit", thresholds: &simulation.Thresholds{Latency: map[string]duration.Duration{"p95": 0}}, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.thresholds.Validate()
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+type recordingSink struct {
+	mutex  sync.Mutex
+	events []*events.Event
+}
+
+func (s *recordingSink) Write(event *events.Event) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestSimulationEvents(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /orders", func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusCreated)
+		fmt.Fprint(res, `{"id": "order-1"}`)
+	})
+	mux.HandleFunc("GET /orders/order-1", func(res http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(res, `{"status": "shipped"}`)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	create := api.NewClient(api.NewClientParams(http.MethodPost, server.URL+"/orders", "application/json", nil))
+	status := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+"/orders/{{.id}}", "", nil))
+
+	target := simulation.NewSimulationTarget("orders", 2, 0, []*simulation.SimulationStep{
+		simulation.NewSimulationStep("create", create, extract.Rules{"id": {JSONPath: "$.id"}}, nil, simulation.NewSimulationMonitorConfig("status", []*monitor.MonitorTarget{
+			monitor.NewMonitorTarget(status, map[string]any{"status": "shipped"}, 10*time.Millisecond, 2),
+		})),
+	})
+
+	sink := &recordingSink{}
+	ctx := events.NewContext(t.Context(), events.NewEmitter(sink))
+	simulation.NewSimulation("Events", []*simulation.SimulationTarget{target}, 1, 0, 10, false).Start(ctx)
+
+	counts := map[string]int{}
+	for _, event := range sink.events {
+		counts[event.Type]++
+
+		if event.SimulationID == "" || event.Simulation != "Events" {
+			t.Errorf("event %+v is missing the simulation", event)
+		}
+
+		isTask := event.Type != events.SimulationStart && event.Type != events.SimulationEnd
+		if isTask && (event.Task == "" || event.Data["target"] != "orders") {
+			t.Errorf("event %+v is missing the task", event)
+		}
+	}
+
+	want := map[string]int{
+		events.SimulationStart:  1,
+		events.RequestSent:      2,
+		events.ResponseReceived: 2,
+		events.MonitorPoll:      2,
+		events.MonitorSatisfied: 2,
+		events.SimulationEnd:    1,
+	}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("event counts = %v, want %v", counts, want)
+	}
+
+	if first, last := sink.events[0], sink.events[len(sink.events)-1]; first.Type != events.SimulationStart || last.Type != events.SimulationEnd {
+		t.Errorf("events start with %s and end with %s", first.Type, last.Type)
+	}
+}
+
+func TestSimulationData(t *testing.T) {
+	var mu sync.Mutex
+	var emails []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(res http.ResponseWriter, req *http.Request) {
+		var payload map[string]any
+		json.NewDecoder(req.Body).Decode(&payload)
+
+		mu.Lock()
+		emails = append(emails, payload["email"].(string))
+		mu.Unlock()
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	users := filepath.Join(t.TempDir(), "users.csv")
+	if err := os.WriteFile(users, []byte("email\na@example.com\nb@example.com\nc@example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &simulation.SimulationConfig{
+		Name: "Data",
+		Target: simulation.SimulationTargetConfig{
+			Count: 2,
+			Client: &api.ClientConfig{
+				Method:      http.MethodPost,
+				Url:         server.URL + "/login",
+				ContentType: "application/json",
+				Body:        json.RawMessage(`{"email": "{{.users.email}}"}`),
+			},
+		},
+		Attempts: 3,
+		Data:     feeder.Sources{"users": {File: users, Mode: feeder.ModeUnique}},
+	}
+
+	plan, err := simulation.NewSimulationFromConfig(config, 10, true)
+	if err != nil {
+		t.Fatalf("NewSimulationFromConfig() error: %v", err)
+	}
+	if clients := plan.Plan().Clients; len(clients) != 3 || clients[2].Steps[0].Request.Body != `{"email":"c@example.com"}` {
+		t.Errorf("plan = %+v, want a client for each of the 3 users", clients)
+	}
+
+	sim, err := simulation.NewSimulationFromConfig(config, 10, false)
+	if err != nil {
+		t.Fatalf("NewSimulationFromConfig() error: %v", err)
+	}
 
-			sim.Start()
+	result := sim.Start(t.Context())
+	if result.Summary.Iterations != 3 {
+		t.Errorf("ran %d iterations, want 3, one for each user", result.Summary.Iterations)
+	}
+
+	slices.Sort(emails)
+	if want := []string{"a@example.com", "b@example.com", "c@example.com"}; !slices.Equal(emails, want) {
+		t.Errorf("logged in as %v, want %v", emails, want)
+	}
+
+	config.Data["users"].File = filepath.Join(t.TempDir(), "missing.csv")
+	if _, err := simulation.NewSimulationFromConfig(config, 10, false); err == nil {
+		t.Errorf("NewSimulationFromConfig() with a missing data file should fail")
+	}
+}
+
+func TestSimulationMonitorOnly(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(res http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		polls++
+		status := "pending"
+		if polls > 2 {
+			status = "ready"
+		}
+		mu.Unlock()
+
+		res.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(res, `{"status": %q}`, status)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	status := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+"/status", "", nil))
+	steps := []*simulation.SimulationStep{
+		simulation.NewSimulationStep("", nil, nil, nil, simulation.NewSimulationMonitorConfig("ready", []*monitor.MonitorTarget{
+			monitor.NewMonitorTarget(status, map[string]any{"status": "ready"}, 10*time.Millisecond, 5),
+		})),
+	}
+
+	sim := simulation.NewSimulation("Monitor Only", []*simulation.SimulationTarget{simulation.NewSimulationTarget("status", 1, 0, steps)}, 1, 0, 10, false)
+
+	var text strings.Builder
+	if err := sim.Plan().WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "1. monitor ready: no request") {
+		t.Errorf("text plan does not show a step without a request:\n%s", text.String())
+	}
+
+	result := sim.Start(t.Context())
+	if result.Summary.Requests != 0 || len(result.Requests) != 0 {
+		t.Errorf("monitor only simulation sent %d request(s)", result.Summary.Requests)
+	}
+	if result.Summary.Monitors != 1 || result.Summary.SatisfiedMonitors != 1 {
+		t.Fatalf("summary = %+v, want one satisfied monitor", result.Summary)
+	}
+	if m := result.Monitors[0]; m.Step != "monitor ready" || m.Polls != 3 {
+		t.Errorf("monitor = %+v, want 3 polls by step \"monitor ready\"", m)
+	}
+}
+
+func TestStages(t *testing.T) {
+	ramp := simulation.Stages{
+		{Duration: duration.Duration(10 * time.Second), Rate: 10},
+		{Duration: duration.Duration(10 * time.Second), Rate: 10},
+		{Duration: 0, Rate: 30},
+		{Duration: duration.Duration(10 * time.Second), Rate: 0},
+	}
+
+	tests := []struct {
+		name       string
+		elapsed    time.Duration
+		load       float64
+		iterations float64
+	}{
+		{name: "Start", elapsed: 0, load: 0, iterations: 0},
+		{name: "RampingUp", elapsed: 5 * time.Second, load: 5, iterations: 12.5},
+		{name: "Steady", elapsed: 15 * time.Second, load: 10, iterations: 100},
+		{name: "Spike", elapsed: 20 * time.Second, load: 30, iterations: 150},
+		{name: "RampingDown", elapsed: 25 * time.Second, load: 15, iterations: 262.5},
+		{name: "Finished", elapsed: time.Minute, load: 0, iterations: 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if load := ramp.At(tt.elapsed); math.Abs(load-tt.load) > 1e-9 {
+				t.Errorf("At(%s) = %v, want %v", tt.elapsed, load, tt.load)
+			}
+			if iterations := ramp.Iterations(tt.elapsed); math.Abs(iterations-tt.iterations) > 1e-9 {
+				t.Errorf("Iterations(%s) = %v, want %v", tt.elapsed, iterations, tt.iterations)
+			}
+		})
+	}
+
+	if ramp.Duration() != 30*time.Second || !ramp.ByRate() {
+		t.Errorf("Duration() = %s, ByRate() = %v, want 30s by rate", ramp.Duration(), ramp.ByRate())
+	}
+}
+
+func TestStagesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		stages  simulation.Stages
+		wantErr bool
+	}{
+		{name: "None", stages: nil},
+		{name: "Clients", stages: simulation.Stages{{Duration: duration.Duration(time.Minute), Target: 10}, {Duration: duration.Duration(time.Minute)}}},
+		{name: "Rate", stages: simulation.Stages{{Rate: 5}, {Duration: duration.Duration(time.Minute), Rate: 5}}},
+		{name: "Mixed", stages: simulation.Stages{{Duration: duration.Duration(time.Minute), Target: 10}, {Duration: duration.Duration(time.Minute), Rate: 5}}, wantErr: true},
+		{name: "Negative", stages: simulation.Stages{{Duration: duration.Duration(-time.Second), Target: -1}}, wantErr: true},
+		{name: "NoDuration", stages: simulation.Stages{{Target: 10}}, wantErr: true},
+		{name: "Empty", stages: simulation.Stages{nil}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.stages.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSimulationStages(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := api.NewClient(api.NewClientParams(http.MethodGet, server.URL, "", nil))
+	target := simulation.NewSimulationTarget("orders", 1, 0, []*simulation.SimulationStep{simulation.NewSimulationStep("", client, nil, nil, nil)})
+
+	rate := simulation.NewSimulation("Rate Stages", []*simulation.SimulationTarget{target}, 0, 0, 10, false, simulation.WithStages(simulation.Stages{
+		{Rate: 100},
+		{Duration: duration.Duration(200 * time.Millisecond), Rate: 100},
+	}))
+
+	result := rate.Start(t.Context())
+	if iterations := result.Summary.Iterations; iterations < 19 || iterations > 20 {
+		t.Errorf("rate stages ran %d iterations, want 20", iterations)
+	}
+
+	clients := simulation.NewSimulation("Client Stages", []*simulation.SimulationTarget{target}, 0, 0, 10, false, simulation.WithStages(simulation.Stages{
+		{Target: 3},
+		{Duration: duration.Duration(200 * time.Millisecond), Target: 3},
+	}))
+
+	mu.Lock()
+	maxInFlight = 0
+	mu.Unlock()
+
+	result = clients.Start(t.Context())
+	if maxInFlight != 3 {
+		t.Errorf("client stages had %d requests in flight at once, want 3", maxInFlight)
+	}
+	if result.Summary.Iterations <= 3 {
+		t.Errorf("client stages ran %d iterations, want clients to keep iterating", result.Summary.Iterations)
+	}
+
+	clientNumbers := map[int]bool{}
+	for _, request := range result.Requests {
+		clientNumbers[request.Client] = true
+	}
+	if len(clientNumbers) != 3 {
+		t.Errorf("requests came from clients %v, want 3 clients", clientNumbers)
+	}
+
+	var text strings.Builder
+	if err := clients.Plan().WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"2 stage(s) over 200ms", "stage 1: jump to 3 client(s)", "stage 2: 200ms to 3 client(s)"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text plan does not contain %q:\n%s", want, text.String())
+		}
+	}
+}
+
+func TestSimulationArrivalDropsKeepData(t *testing.T) {
+	var mu sync.Mutex
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		ids = append(ids, req.URL.Query().Get("id"))
+		mu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	rows := make([]map[string]any, 0, 10)
+	for i := range 10 {
+		rows = append(rows, map[string]any{"id": fmt.Sprint(i)})
+	}
+
+	client := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+"/orders?id={{.orders.id}}", "", nil))
+	target := simulation.NewSimulationTarget("orders", 1, 0, []*simulation.SimulationStep{simulation.NewSimulationStep("", client, nil, nil, nil)})
+	sim := simulation.NewSimulation("Dropped Arrival", []*simulation.SimulationTarget{target}, 10, 0, 10, false,
+		simulation.WithArrival(&simulation.Arrival{Rate: 100, MaxInFlight: 1}),
+		simulation.WithData(map[string]*feeder.Feeder{"orders": feeder.NewFeeder(feeder.ModeUnique, rows)}))
+
+	result := sim.Start(t.Context())
+	if result.Summary.DroppedIterations == 0 {
+		t.Fatalf("arrival dropped no iterations, want iterations due while the only worker was busy dropped")
+	}
+
+	// Only the iterations that ran take a row, so they used the first rows.
+	want := make([]string, 0, result.Summary.Iterations)
+	for i := range result.Summary.Iterations {
+		want = append(want, fmt.Sprint(i))
+	}
+	if !slices.Equal(ids, want) {
+		t.Errorf("sent ids %v, want %v", ids, want)
+	}
+}
+
+func TestArrivalValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		arrival *simulation.Arrival
+		wantErr bool
+	}{
+		{name: "None", arrival: nil},
+		{name: "Constant", arrival: &simulation.Arrival{Rate: 50}},
+		{name: "Poisson", arrival: &simulation.Arrival{Rate: 50, Distribution: "Poisson", MaxInFlight: 20}},
+		{name: "NegativeRate", arrival: &simulation.Arrival{Rate: -1}, wantErr: true},
+		{name: "UnknownDistribution", arrival: &simulation.Arrival{Rate: 50, Distribution: "normal"}, wantErr: true},
+		{name: "TooManyInFlight", arrival: &simulation.Arrival{Rate: 50, MaxInFlight: 1000}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.arrival.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSimulationArrival(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	newTarget := func(path string) *simulation.SimulationTarget {
+		client := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+path, "", nil))
+		return simulation.NewSimulationTarget("orders", 2, 0, []*simulation.SimulationStep{simulation.NewSimulationStep("", client, nil, nil, nil)})
+	}
+
+	constant := simulation.NewSimulation("Constant Arrival", []*simulation.SimulationTarget{newTarget("/fast")}, 5, 0, 10, false,
+		simulation.WithArrival(&simulation.Arrival{Rate: 100}))
+
+	start := time.Now()
+	result := constant.Start(t.Context())
+	elapsed := time.Since(start)
+
+	if result.Summary.Iterations != 10 || result.Summary.DroppedIterations != 0 {
+		t.Errorf("constant arrival ran %d iterations and dropped %d, want 10 and 0", result.Summary.Iterations, result.Summary.DroppedIterations)
+	}
+	if elapsed < 80*time.Millisecond {
+		t.Errorf("constant arrival finished in %s, want iterations spread over at least 90ms", elapsed)
+	}
+
+	var text strings.Builder
+	if err := constant.Plan().WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"arriving at 100 iteration(s)/s (constant)", "+90ms attempt 4, client 1"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text plan does not contain %q:\n%s", want, text.String())
+		}
+	}
+
+	saturated := simulation.NewSimulation("Saturated Arrival", []*simulation.SimulationTarget{newTarget("/slow")}, 5, 0, 10, false,
+		simulation.WithArrival(&simulation.Arrival{Rate: 100, MaxInFlight: 1}))
+
+	result = saturated.Start(t.Context())
+	if result.Summary.Iterations+result.Summary.DroppedIterations != 10 {
+		t.Errorf("saturated arrival ran %d iterations and dropped %d, want 10 together", result.Summary.Iterations, result.Summary.DroppedIterations)
+	}
+	if result.Summary.DroppedIterations == 0 {
+		t.Errorf("saturated arrival dropped no iterations, want iterations due while the only worker was busy dropped")
+	}
+	if len(result.Targets) != 1 || result.Targets[0].DroppedIterations != result.Summary.DroppedIterations {
+		t.Errorf("target dropped iterations do not match the summary")
+	}
+
+	var summary strings.Builder
+	if err := result.WriteSummary(&summary); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(summary.String(), "iteration(s) dropped") {
+		t.Errorf("summary does not mention dropped iterations:\n%s", summary.String())
+	}
+}
+
+func TestSimulationVirtualUsers(t *testing.T) {
+	type login struct {
+		client    string
+		iteration string
+		previous  string
+		cookie    string
+	}
+
+	var mu sync.Mutex
+	var logins []login
+
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		if req.URL.Path != "/login" {
+			return
+		}
+
+		l := login{client: query.Get("client"), iteration: query.Get("iteration"), previous: query.Get("previous")}
+		if cookie, err := req.Cookie("session"); err == nil {
+			l.cookie = cookie.Value
+		} else {
+			http.SetCookie(res, &http.Cookie{Name: "session", Value: "user-" + l.client})
+		}
+
+		mu.Lock()
+		logins = append(logins, l)
+		mu.Unlock()
+
+		fmt.Fprintf(res, `{"token": "t-%s-%s"}`, l.client, l.iteration)
+	}))
+	defer server.Close()
+
+	loginClient := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+`/login?client={{.client}}&iteration={{.iteration}}&previous={{with index . "token"}}{{.}}{{end}}`, "", nil))
+	profileClient := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+"/profile?token={{.token}}", "", nil))
+
+	target := simulation.NewSimulationTarget("users", 2, 0, []*simulation.SimulationStep{
+		simulation.NewSimulationStep("login", loginClient, extract.Rules{"token": {JSONPath: "$.token"}}, nil, nil),
+		simulation.NewSimulationStep("profile", profileClient, nil, nil, nil),
+	}, simulation.WithThinkTime(&simulation.ThinkTime{Min: duration.Duration(20 * time.Millisecond)}))
+
+	result := simulation.NewSimulation("Virtual Users", []*simulation.SimulationTarget{target}, 3, 0, 4, false).Start(t.Context())
+	if result.Summary.Iterations != 6 || result.Summary.FailedRequests != 0 {
+		t.Fatalf("ran %d iterations with %d failed request(s), want 6 and 0", result.Summary.Iterations, result.Summary.FailedRequests)
+	}
+
+	seen := map[string][]string{}
+	for _, l := range logins {
+		seen[l.client] = append(seen[l.client], l.iteration)
+
+		if l.iteration == "0" {
+			if l.cookie != "" || l.previous != "" {
+				t.Errorf("client %s first iteration sent cookie %q and token %q, want a fresh user", l.client, l.cookie, l.previous)
+			}
+			continue
+		}
+
+		if l.cookie != "user-"+l.client {
+			t.Errorf("client %s iteration %s sent cookie %q, want the user's own cookie", l.client, l.iteration, l.cookie)
+		}
+
+		var iteration int
+		fmt.Sscan(l.iteration, &iteration)
+		if want := fmt.Sprintf("t-%s-%d", l.client, iteration-1); l.previous != want {
+			t.Errorf("client %s iteration %s sent token %q, want %q from the previous iteration", l.client, l.iteration, l.previous, want)
+		}
+	}
+
+	for client, iterations := range seen {
+		if strings.Join(iterations, ",") != "0,1,2" {
+			t.Errorf("client %s ran iterations %v, want 0, 1 and 2 in order", client, iterations)
+		}
+	}
+
+	for i := 0; i+1 < len(result.Requests); i++ {
+		first, second := result.Requests[i], result.Requests[i+1]
+		if first.Step != "login" || second.Step != "profile" || first.Client != second.Client || first.Attempt != second.Attempt {
+			continue
+		}
+
+		if gap := second.StartedAt.Sub(first.StartedAt); gap < 20*time.Millisecond {
+			t.Errorf("client %d attempt %d sent profile %s after login, want at least the 20ms think time", first.Client, first.Attempt, gap)
+		}
+	}
+}
+
+func TestSimulationMonitorSession(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /login", func(res http.ResponseWriter, req *http.Request) {
+		http.SetCookie(res, &http.Cookie{Name: "session", Value: "user-" + req.URL.Query().Get("client")})
+	})
+	mux.HandleFunc("GET /status", func(res http.ResponseWriter, req *http.Request) {
+		cookie, err := req.Cookie("session")
+		if err != nil {
+			res.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(res, `{"user": %q}`, cookie.Value)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	login := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+"/login?client={{.client}}", "", nil))
+	status := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+"/status", "", nil))
+
+	target := simulation.NewSimulationTarget("users", 2, 0, []*simulation.SimulationStep{
+		simulation.NewSimulationStep("login", login, nil, nil, simulation.NewSimulationMonitorConfig("status", []*monitor.MonitorTarget{
+			monitor.NewMonitorTarget(status, map[string]any{"user": "user-{{.client}}"}, 10*time.Millisecond, 2),
+		})),
+	})
+
+	result := simulation.NewSimulation("Monitor Session", []*simulation.SimulationTarget{target}, 1, 0, 4, false).Start(t.Context())
+	if result.Summary.Monitors != 2 || result.Summary.SatisfiedMonitors != 2 {
+		t.Fatalf("summary = %+v, want both users' monitors satisfied with their own session", result.Summary)
+	}
+}
+
+func TestThinkTimeValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		thinkTime *simulation.ThinkTime
+		wantErr   bool
+	}{
+		{name: "None", thinkTime: nil},
+		{name: "Fixed", thinkTime: &simulation.ThinkTime{Min: duration.Duration(time.Second)}},
+		{name: "Range", thinkTime: &simulation.ThinkTime{Min: duration.Duration(time.Second), Max: duration.Duration(3 * time.Second)}},
+		{name: "Negative", thinkTime: &simulation.ThinkTime{Min: duration.Duration(-time.Second)}, wantErr: true},
+		{name: "MaxBelowMin", thinkTime: &simulation.ThinkTime{Min: duration.Duration(3 * time.Second), Max: duration.Duration(time.Second)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.thinkTime.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSimulationDuration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		delay, _ := time.ParseDuration(req.URL.Query().Get("delay"))
+		select {
+		case <-req.Context().Done():
+		case <-time.After(delay):
+		}
+	}))
+	defer server.Close()
+
+	newTarget := func(delay string) *simulation.SimulationTarget {
+		client := api.NewClient(api.NewClientParams(http.MethodGet, server.URL+"?delay="+delay, "", nil))
+		return simulation.NewSimulationTarget("orders", 2, 0, []*simulation.SimulationStep{simulation.NewSimulationStep("", client, nil, nil, nil)})
+	}
+
+	tests := []struct {
+		name          string
+		delay         string
+		duration      time.Duration
+		grace         time.Duration
+		minIterations int
+		maxElapsed    time.Duration
+		failed        bool
+	}{
+		{name: "KeepsIterating", delay: "5ms", duration: 150 * time.Millisecond, grace: time.Second, minIterations: 10, maxElapsed: time.Second},
+		{name: "DrainsWithinGrace", delay: "100ms", duration: 50 * time.Millisecond, grace: time.Second, minIterations: 2, maxElapsed: 500 * time.Millisecond},
+		{name: "CancelsAfterGrace", delay: "5s", duration: 50 * time.Millisecond, grace: 50 * time.Millisecond, minIterations: 2, maxElapsed: time.Second, failed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := simulation.NewSimulation("Duration", []*simulation.SimulationTarget{newTarget(tt.delay)}, 0, 0, 4, false,
+				simulation.WithDuration(tt.duration, tt.grace))
+
+			start := time.Now()
+			result := sim.Start(t.Context())
+			elapsed := time.Since(start)
+
+			if elapsed < tt.duration || elapsed > tt.maxElapsed {
+				t.Errorf("Start() took %s, want between %s and %s", elapsed, tt.duration, tt.maxElapsed)
+			}
+			if result.Summary.Iterations < tt.minIterations {
+				t.Errorf("Start() ran %d iterations, want at least %d", result.Summary.Iterations, tt.minIterations)
+			}
+			if failed := result.Summary.FailedRequests > 0; failed != tt.failed {
+				t.Errorf("Start() had %d failed request(s), want failures %v", result.Summary.FailedRequests, tt.failed)
+			}
+			if result.TimedOut {
+				t.Errorf("Start() timed out, want a duration-bound run to end on its own")
+			}
 		})
 	}
+
+	sim := simulation.NewSimulation("Soak", []*simulation.SimulationTarget{newTarget("5ms")}, 0, 0, 1000, false,
+		simulation.WithDuration(10*time.Minute, 30*time.Second))
+
+	var text strings.Builder
+	if err := sim.Plan().WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if want := `Simulation "Soak": 2 client(s) iterating for 10m0s, 30s grace period, up to 64 workers`; !strings.Contains(text.String(), want) {
+		t.Errorf("text plan does not contain %q:\n%s", want, text.String())
+	}
+}
+
+func TestSimulationDurationSamplesFailures(t *testing.T) {
+	var sent atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if sent.Add(1)%2 == 0 {
+			res.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := api.NewClient(api.NewClientParams(http.MethodGet, server.URL, "", nil))
+	target := simulation.NewSimulationTarget("orders", 2, 0, []*simulation.SimulationStep{simulation.NewSimulationStep("", client, nil, nil, nil)})
+	result := simulation.NewSimulation("Soak", []*simulation.SimulationTarget{target}, 0, 0, 4, false,
+		simulation.WithDuration(300*time.Millisecond, time.Second)).Start(t.Context())
+
+	summary := result.Summary
+	if summary.FailedRequests <= simulation.MaxSampledFailures {
+		t.Fatalf("run failed %d request(s), want more than %d to sample from", summary.FailedRequests, simulation.MaxSampledFailures)
+	}
+
+	if !result.Sampled || len(result.Requests) != simulation.MaxSampledFailures {
+		t.Errorf("result kept %d request(s), sampled %v, want the first %d failures", len(result.Requests), result.Sampled, simulation.MaxSampledFailures)
+	}
+	for _, request := range result.Requests {
+		if !request.Failed {
+			t.Fatalf("result kept request %+v, want only failures", request)
+		}
+	}
+
+	if result.Statuses[http.StatusOK]+result.Statuses[http.StatusInternalServerError] != summary.Requests || result.Latency[0].Count != int64(summary.Requests) {
+		t.Errorf("statuses %v and latency count %d, want them to cover all %d requests", result.Statuses, result.Latency[0].Count, summary.Requests)
+	}
+}
+
+func TestSimulationDurationKeepsConsistency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(res, `{"status": "ready"}`)
+	}))
+	defer server.Close()
+
+	status := api.NewClient(api.NewClientParams(http.MethodGet, server.URL, "", nil))
+	target := simulation.NewSimulationTarget("orders", 1, 0, []*simulation.SimulationStep{
+		simulation.NewSimulationStep("", nil, nil, nil, simulation.NewSimulationMonitorConfig("ready", []*monitor.MonitorTarget{
+			monitor.NewMonitorTarget(status, map[string]any{"status": "ready"}, 10*time.Millisecond, 2),
+		})),
+	})
+	result := simulation.NewSimulation("Soak", []*simulation.SimulationTarget{target}, 0, 20*time.Millisecond, 4, false,
+		simulation.WithDuration(100*time.Millisecond, time.Second)).Start(t.Context())
+
+	if len(result.Monitors) != 0 {
+		t.Errorf("result kept %d satisfied monitor(s), want none", len(result.Monitors))
+	}
+
+	if len(result.Consistency) != 1 {
+		t.Fatalf("result has %d consistency result(s), want 1", len(result.Consistency))
+	}
+	if c := result.Consistency[0]; c.Satisfied == 0 || c.Satisfied != result.Summary.SatisfiedMonitors || c.Max < c.Mean {
+		t.Errorf("consistency = %+v, want it to cover all %d satisfied monitors", c, result.Summary.SatisfiedMonitors)
+	}
+}
+
+func TestSimulationDurationPacing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		cadence       time.Duration
+		thinkTime     *simulation.ThinkTime
+		maxIterations int
+	}{
+		{name: "Cadence", cadence: 50 * time.Millisecond, maxIterations: 5},
+		{name: "ThinkTime", thinkTime: &simulation.ThinkTime{Min: duration.Duration(50 * time.Millisecond)}, maxIterations: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := api.NewClient(api.NewClientParams(http.MethodGet, server.URL, "", nil))
+			target := simulation.NewSimulationTarget("orders", 1, 0, []*simulation.SimulationStep{simulation.NewSimulationStep("", client, nil, nil, nil)}, simulation.WithThinkTime(tt.thinkTime))
+			sim := simulation.NewSimulation("Paced", []*simulation.SimulationTarget{target}, 0, tt.cadence, 4, false,
+				simulation.WithDuration(200*time.Millisecond, time.Second))
+
+			start := time.Now()
+			result := sim.Start(t.Context())
+			elapsed := time.Since(start)
+
+			if iterations := result.Summary.Iterations; iterations < 2 || iterations > tt.maxIterations {
+				t.Errorf("Start() ran %d iterations, want between 2 and %d", iterations, tt.maxIterations)
+			}
+			if elapsed > 400*time.Millisecond {
+				t.Errorf("Start() took %s, want a waiting client to stop when the duration ends", elapsed)
+			}
+		})
+	}
+
+	sim := simulation.NewSimulation("Paced", []*simulation.SimulationTarget{simulation.NewSimulationTarget("orders", 1, 0, []*simulation.SimulationStep{
+		simulation.NewSimulationStep("", api.NewClient(api.NewClientParams(http.MethodGet, server.URL, "", nil)), nil, nil, nil),
+	})}, 0, time.Minute, 4, false, simulation.WithDuration(10*time.Minute, 30*time.Second))
+
+	var text strings.Builder
+	if err := sim.Plan().WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if want := "each client starts iterations at least 1m0s apart"; !strings.Contains(text.String(), want) {
+		t.Errorf("text plan does not contain %q:\n%s", want, text.String())
+	}
 }
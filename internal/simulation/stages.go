@@ -0,0 +1,383 @@
+package simulation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/threadpool"
+)
+
+// stageTick is how often a staged simulation adjusts its load.
+const stageTick = 10 * time.Millisecond
+
+// Stage is one part of a load profile. Over Duration the load moves in a
+// straight line from where the previous stage ended, or from nothing for the
+// first stage, to Target concurrent clients or Rate iterations per second. A
+// stage without a duration jumps straight to its load, to start at a steady
+// load or to model a spike.
+type Stage struct {
+	Duration duration.Duration `json:"duration"`
+	Target   int               `json:"target,omitempty"`
+	Rate     float64           `json:"rate,omitempty"`
+}
+
+// Stages is a load profile. Every stage sets either Target or Rate, never a
+// mix of both.
+type Stages []*Stage
+
+func (s Stages) Validate() error {
+	if len(s) == 0 {
+		return nil
+	}
+
+	var errs []error
+	byRate := s.ByRate()
+	for i, stage := range s {
+		if stage == nil {
+			errs = append(errs, fmt.Errorf("stage %d can not be empty", i+1))
+			continue
+		}
+
+		if stage.Duration < 0 {
+			errs = append(errs, fmt.Errorf("stage %d duration can not be negative, got %s", i+1, stage.Duration))
+		}
+
+		if stage.Target < 0 || stage.Rate < 0 {
+			errs = append(errs, fmt.Errorf("stage %d target and rate can not be negative", i+1))
+		}
+
+		if byRate && stage.Target != 0 {
+			errs = append(errs, fmt.Errorf("stage %d sets a target but other stages set a rate, every stage must use the same one", i+1))
+		}
+	}
+
+	if s.Duration() <= 0 {
+		errs = append(errs, fmt.Errorf("stages must last longer than 0s"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ByRate reports whether the stages set iterations per second rather than
+// concurrent clients.
+func (s Stages) ByRate() bool {
+	for _, stage := range s {
+		if stage != nil && stage.Rate != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Duration returns how long the stages last together.
+func (s Stages) Duration() time.Duration {
+	var total time.Duration
+	for _, stage := range s {
+		if stage != nil {
+			total += stage.Duration.Std()
+		}
+	}
+
+	return total
+}
+
+// PeakTarget returns the most concurrent clients any stage asks for.
+func (s Stages) PeakTarget() int {
+	peak := 0
+	for _, stage := range s {
+		if stage != nil {
+			peak = max(peak, stage.Target)
+		}
+	}
+
+	return peak
+}
+
+func (s *Stage) load() float64 {
+	if s.Rate != 0 {
+		return s.Rate
+	}
+
+	return float64(s.Target)
+}
+
+// At returns the load elapsed into the stages.
+func (s Stages) At(elapsed time.Duration) float64 {
+	from := 0.0
+	for _, stage := range s {
+		to := stage.load()
+		d := stage.Duration.Std()
+		if elapsed < d {
+			return from + (to-from)*float64(elapsed)/float64(d)
+		}
+
+		elapsed -= d
+		from = to
+	}
+
+	return from
+}
+
+// Iterations returns how many iterations rate stages start in the first
+// elapsed of the run, the area under the load.
+func (s Stages) Iterations(elapsed time.Duration) float64 {
+	total := 0.0
+	from := 0.0
+	for _, stage := range s {
+		to := stage.load()
+		d := stage.Duration.Std()
+		if elapsed < d {
+			at := from + (to-from)*float64(elapsed)/float64(d)
+			return total + (from+at)/2*elapsed.Seconds()
+		}
+
+		total += (from + to) / 2 * d.Seconds()
+		elapsed -= d
+		from = to
+	}
+
+	return total + from*elapsed.Seconds()
+}
+
+// String describes the stage, for plans and logs.
+func (s *Stage) String() string {
+	load := fmt.Sprintf("%d client(s)", s.Target)
+	if s.Rate != 0 {
+		load = fmt.Sprintf("%g iteration(s)/s", s.Rate)
+	}
+
+	if s.Duration == 0 {
+		return "jump to " + load
+	}
+
+	return fmt.Sprintf("%s to %s", s.Duration, load)
+}
+
+// rotation hands out the clients of an attempt over and over, so the nth
+// client of a staged run gets a target, and a client number within that
+// target, as if the attempts ran back to back.
+type rotation struct {
+	targets    []*SimulationTarget
+	clients    []int
+	perAttempt map[*SimulationTarget]int
+}
+
+func newRotation(schedule []*SimulationTarget) *rotation {
+	r := &rotation{
+		targets:    schedule,
+		clients:    make([]int, len(schedule)),
+		perAttempt: make(map[*SimulationTarget]int),
+	}
+
+	for i, target := range schedule {
+		r.clients[i] = r.perAttempt[target]
+		r.perAttempt[target]++
+	}
+
+	return r
+}
+
+// slot returns the target, attempt and client number of the nth client.
+func (r *rotation) slot(n int) (*SimulationTarget, int, int) {
+	attempt, i := n/len(r.targets), n%len(r.targets)
+	target := r.targets[i]
+
+	return target, attempt, attempt*r.perAttempt[target] + r.clients[i]
+}
+
+// newTaskFunc builds the task of a single iteration of a client, or returns
+// nil when there is no more data to give it.
+type newTaskFunc func(target *SimulationTarget, attempt int, client int) *SimulationTask
+
+// runClientStages keeps as many clients iterating as stages ask for, each
+// starting its iterations at least cadence apart. A client that is no longer
+// needed stops after its current iteration.
+func (s *Simulation) runClientStages(ctx context.Context, tp *threadpool.ThreadPool, stages Stages, cadence time.Duration, newTask newTaskFunc) {
+	r := newRotation(s.schedule())
+	loops := &clientLoops{active: map[int]bool{}, iterations: map[int]int{}, wake: make(chan struct{})}
+	end := stages.Duration()
+	ticker := time.NewTicker(stageTick)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for {
+		elapsed := min(time.Since(start), end)
+		want := 0
+		if elapsed < end && ctx.Err() == nil {
+			want = int(math.Round(stages.At(elapsed)))
+		}
+
+		for _, n := range loops.scale(want) {
+			target, _, client := r.slot(n)
+			tp.Add(&clientLoop{
+				name:    fmt.Sprintf("%s %s client %d", s.name, target.name, client),
+				n:       n,
+				loops:   loops,
+				ctx:     ctx,
+				target:  target,
+				client:  client,
+				cadence: cadence,
+				new:     newTask,
+			})
+		}
+
+		if want == 0 && (elapsed >= end || ctx.Err() != nil) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+	}
+}
+
+// stageWorkers returns how many clients a staged simulation can run at once.
+func (s *Simulation) stageWorkers() int {
+	return min(s.workers, threadpool.MAX_WORKERS)
+}
+
+// clientLoops tracks which clients of a staged run are iterating and how many
+// clients are wanted. wake is closed whenever clients stop being wanted, so
+// clients waiting between iterations can stop straight away.
+type clientLoops struct {
+	mutex      sync.Mutex
+	want       int
+	stopped    bool
+	active     map[int]bool
+	iterations map[int]int
+	wake       chan struct{}
+}
+
+// scale sets how many clients are wanted and returns the clients to start.
+func (l *clientLoops) scale(want int) []int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.stopped {
+		want = 0
+	}
+	if want < l.want {
+		l.wakeAll()
+	}
+	l.want = want
+
+	var start []int
+	for n := range want {
+		if !l.active[n] {
+			l.active[n] = true
+			start = append(start, n)
+		}
+	}
+
+	return start
+}
+
+// next returns the iteration client n runs next, or false when the client is
+// no longer wanted.
+func (l *clientLoops) next(n int) (int, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.stopped || n >= l.want {
+		delete(l.active, n)
+		return 0, false
+	}
+
+	iteration := l.iterations[n]
+	l.iterations[n]++
+
+	return iteration, true
+}
+
+// wait waits d before client n starts its next iteration, returning early
+// when ctx is done or the client is no longer wanted.
+func (l *clientLoops) wait(ctx context.Context, n int, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	l.mutex.Lock()
+	wake := l.wake
+	wanted := !l.stopped && n < l.want
+	l.mutex.Unlock()
+
+	if !wanted {
+		return
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-wake:
+	case <-timer.C:
+	}
+}
+
+// stop stops every client after its current iteration.
+func (l *clientLoops) stop() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.stopped = true
+	l.wakeAll()
+}
+
+// wakeAll wakes every client waiting between iterations. The caller must hold
+// the mutex.
+func (l *clientLoops) wakeAll() {
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
+
+// clientLoop runs iterations of one client of a staged run for as long as the
+// client is wanted. Between iterations the client waits its target's think
+// time, and longer if needed to start iterations at least cadence apart.
+type clientLoop struct {
+	name    string
+	n       int
+	loops   *clientLoops
+	ctx     context.Context
+	target  *SimulationTarget
+	client  int
+	cadence time.Duration
+	new     newTaskFunc
+}
+
+func (l *clientLoop) GetName() string {
+	return l.name
+}
+
+func (l *clientLoop) Run() {
+	var started time.Time
+	for l.ctx.Err() == nil {
+		if !started.IsZero() {
+			l.loops.wait(l.ctx, l.n, max(l.target.thinkTime.next(), l.cadence-time.Since(started)))
+			if l.ctx.Err() != nil {
+				return
+			}
+		}
+
+		iteration, ok := l.loops.next(l.n)
+		if !ok {
+			return
+		}
+
+		task := l.new(l.target, iteration, l.client)
+		if task == nil {
+			l.loops.stop()
+			return
+		}
+
+		started = time.Now()
+		task.Run()
+	}
+}
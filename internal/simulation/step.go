@@ -0,0 +1,281 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/assert"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/events"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/extract"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/monitor"
+)
+
+// SimulationStepConfig describes a single step. A step without a Client sends
+// nothing and only runs its Monitor, to watch endpoints that something else
+// changes.
+type SimulationStepConfig struct {
+	Name    string                 `json:"name"`
+	Client  *api.ClientConfig      `json:"client,omitempty"`
+	Extract extract.Rules          `json:"extract,omitempty"`
+	Assert  *Assertions            `json:"assert,omitempty"`
+	Monitor *monitor.MonitorConfig `json:"monitor,omitempty"`
+}
+
+// Redacted returns a copy of the config with the secrets of its clients
+// replaced, see api.ClientConfig.Redacted.
+func (c *SimulationStepConfig) Redacted() *SimulationStepConfig {
+	if c == nil {
+		return nil
+	}
+
+	redacted := *c
+	redacted.Client = c.Client.Redacted()
+	redacted.Monitor = c.Monitor.Redacted()
+
+	return &redacted
+}
+
+type SimulationStep struct {
+	name       string
+	client     *api.Client
+	extract    extract.Rules
+	assertions *Assertions
+	monitor    *SimulationMonitorConfig
+	logger     *slog.Logger
+}
+
+// NewSimulationStep creates a step that sends a request with client. Without
+// a client the step only runs monitor.
+func NewSimulationStep(name string, client *api.Client, extractRules extract.Rules, assertions *Assertions, monitor *SimulationMonitorConfig) *SimulationStep {
+	assert.Assert(client != nil || monitor != nil, "Simulation step must have a client or a monitor")
+
+	if name == "" && client != nil {
+		name = client.Method() + " " + client.Url()
+	} else if name == "" {
+		name = "monitor " + monitor.name
+	}
+
+	logger := slog.Default().With("area", "SimulationStep "+name)
+
+	return &SimulationStep{
+		name:       name,
+		client:     client,
+		extract:    extractRules,
+		assertions: assertions,
+		monitor:    monitor,
+		logger:     logger,
+	}
+}
+
+func NewSimulationStepFromConfig(config *SimulationStepConfig, index int) *SimulationStep {
+	assert.NotNil(config, "Simulation step config can not be nil")
+	assert.Assert(config.Client != nil || config.Monitor != nil, "Simulation step must have a client or a monitor", "step", index)
+
+	var client *api.Client
+	if config.Client != nil {
+		params, err := api.NewClientParamsFromConfig(config.Client, http.MethodPost)
+		assert.NoError(err, "Simulation step client config must be valid", "step", index)
+		client = api.NewClient(params)
+	}
+
+	var monitorConfig *SimulationMonitorConfig
+	if config.Monitor != nil {
+		monitorConfig = NewSimulationMonitorConfig(config.Monitor.Name, monitor.CreateMonitorTargetsFromConfig(config.Monitor.MonitorTargets))
+	}
+
+	name := config.Name
+	if name == "" {
+		name = fmt.Sprintf("step %d", index+1)
+	}
+
+	return NewSimulationStep(name, client, config.Extract, config.Assert, monitorConfig)
+}
+
+func (s *SimulationStep) Name() string {
+	return s.name
+}
+
+// Run sends the step request with vars as part of session, checks its
+// assertions, adds any extracted values to vars and then runs the step
+// monitor with up to workers targets polled at once. It returns what the step
+// did, along with an error when the request fails or an assertion does not
+// hold, in which case later steps should not run. A step without a client
+// only runs its monitor.
+func (s *SimulationStep) Run(ctx context.Context, session *api.Session, vars map[string]any, workers int) (*StepResult, error) {
+	if s.client == nil {
+		return &StepResult{Step: s.name, Monitors: s.runMonitor(ctx, session, vars, workers)}, nil
+	}
+
+	start := time.Now()
+	request := &RequestResult{
+		Step:      s.name,
+		Method:    s.client.Method(),
+		Url:       s.client.Url(),
+		StartedAt: start,
+	}
+	result := &StepResult{Step: s.name, Request: request}
+
+	req, err := s.client.NewRequest(ctx, vars)
+	if err != nil {
+		request.Failed = true
+		request.Error = err.Error()
+		return result, err
+	}
+
+	emitter := events.FromContext(ctx)
+	fail := func(err error) (*StepResult, error) {
+		request.Failed = true
+		request.Error = err.Error()
+		request.RequestBody = requestBody(req)
+		s.emitResponse(emitter, request)
+		return result, err
+	}
+
+	emitter.Emit(events.RequestSent, map[string]any{
+		"step":   s.name,
+		"method": request.Method,
+		"url":    req.URL.String(),
+	})
+
+	resp, err := s.client.SendWith(session, req)
+	if err != nil {
+		request.Latency = duration.Duration(time.Since(start))
+		return fail(err)
+	}
+
+	assert.NotNil(resp, "Response from Do can not be nil")
+	assert.NotNil(resp.Body, "Response Body can not be nil")
+	defer resp.Body.Close()
+
+	request.Url = resp.Request.URL.String()
+	request.StatusCode = resp.StatusCode
+
+	body, err := io.ReadAll(resp.Body)
+	request.Bytes = int64(len(body))
+	request.Latency = duration.Duration(time.Since(start))
+	if err != nil {
+		return fail(fmt.Errorf("unable to read response body: %w", err))
+	}
+
+	if err := s.assertions.Check(resp, body, vars); err != nil {
+		request.Response = string(body)
+		return fail(err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest && !s.assertions.checksStatus() {
+		request.Failed = true
+		request.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+		request.RequestBody = requestBody(req)
+		request.Response = string(body)
+	}
+	s.emitResponse(emitter, request)
+
+	if len(s.extract) > 0 {
+		extracted, err := s.extract.Apply(resp.Header, body)
+		if err != nil {
+			s.logger.Warn(fmt.Sprintf("Unable to extract all values from response: %s", err.Error()))
+		}
+
+		request.Extracted = extracted
+		maps.Copy(vars, extracted)
+	}
+
+	result.Monitors = s.runMonitor(ctx, session, vars, workers)
+	return result, nil
+}
+
+func (s *SimulationStep) runMonitor(ctx context.Context, session *api.Session, vars map[string]any, workers int) []*monitor.Result {
+	if s.monitor == nil {
+		return nil
+	}
+
+	return monitor.NewMonitor(ctx, s.monitor.name, s.monitor.monitorTargets, maps.Clone(vars), workers, monitor.WithSession(session)).Start()
+}
+
+// emitResponse emits how a request ended, as a failure when no response was
+// received at all.
+func (s *SimulationStep) emitResponse(emitter *events.Emitter, request *RequestResult) {
+	if request.StatusCode == 0 {
+		emitter.Emit(events.RequestFailed, map[string]any{
+			"step":    s.name,
+			"latency": request.Latency.String(),
+			"error":   request.Error,
+		})
+		return
+	}
+
+	emitter.Emit(events.ResponseReceived, map[string]any{
+		"step":       s.name,
+		"statusCode": request.StatusCode,
+		"latency":    request.Latency.String(),
+		"bytes":      request.Bytes,
+		"failed":     request.Failed,
+		"error":      request.Error,
+	})
+}
+
+// requestBody returns a copy of the body req was sent with.
+func requestBody(req *http.Request) string {
+	if req.GetBody == nil {
+		return ""
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+// Plan describes the request the step would send with vars and the monitor it
+// would start. Values the step extracts are added to vars as "<name>"
+// placeholders so later steps can still be planned.
+func (s *SimulationStep) Plan(vars map[string]any) *StepPlan {
+	plan := &StepPlan{Name: s.name}
+
+	if s.client != nil {
+		request, err := s.client.Plan(vars)
+		if err != nil {
+			plan.Error = err.Error()
+		}
+		plan.Request = request
+	}
+
+	for name := range s.extract {
+		plan.Extract = append(plan.Extract, name)
+		vars[name] = "<" + name + ">"
+	}
+	sort.Strings(plan.Extract)
+
+	if s.monitor == nil {
+		return plan
+	}
+
+	plan.Monitor = &MonitorPlan{Name: s.monitor.name}
+	for _, target := range s.monitor.monitorTargets {
+		targetPlan, err := target.Plan(vars)
+		if err != nil {
+			plan.Monitor.Errors = append(plan.Monitor.Errors, err.Error())
+			continue
+		}
+
+		plan.Monitor.Targets = append(plan.Monitor.Targets, targetPlan)
+	}
+
+	return plan
+}
@@ -0,0 +1,195 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/histogram"
+)
+
+// Thresholds are the pass criteria of a simulation. MaxErrorRate is the
+// largest accepted fraction of failed requests, so 0.01 allows 1%. Latency
+// maps a statistic such as "p95", "mean" or "max" to the slowest accepted
+// request latency. MonitorsSatisfied requires every monitor to see its
+// expected response. A run without thresholds is checked against
+// DefaultThresholds.
+type Thresholds struct {
+	MaxErrorRate      *float64                     `json:"maxErrorRate,omitempty"`
+	Latency           map[string]duration.Duration `json:"latency,omitempty"`
+	MonitorsSatisfied bool                         `json:"monitorsSatisfied,omitempty"`
+}
+
+// DefaultThresholds are the thresholds of a run that sets none, which passes
+// only when no request failed and every monitor was satisfied.
+func DefaultThresholds() *Thresholds {
+	maxErrorRate := 0.0
+	return &Thresholds{
+		MaxErrorRate:      &maxErrorRate,
+		MonitorsSatisfied: true,
+	}
+}
+
+// ThresholdResult is the outcome of checking a single threshold.
+type ThresholdResult struct {
+	Name   string `json:"name"`
+	Limit  string `json:"limit"`
+	Actual string `json:"actual"`
+	Passed bool   `json:"passed"`
+}
+
+func (r *ThresholdResult) String() string {
+	verdict := "passed"
+	if !r.Passed {
+		verdict = "breached"
+	}
+
+	return fmt.Sprintf("%s %s: %s, limit %s", r.Name, verdict, r.Actual, r.Limit)
+}
+
+var percentilePattern = regexp.MustCompile(`^p(\d+(?:\.\d+)?)$`)
+
+func (t *Thresholds) Validate() error {
+	if t == nil {
+		return nil
+	}
+
+	var errs []error
+	if t.MaxErrorRate != nil && (*t.MaxErrorRate < 0 || *t.MaxErrorRate > 1) {
+		errs = append(errs, fmt.Errorf("maxErrorRate must be between 0 and 1, got %v", *t.MaxErrorRate))
+	}
+
+	for _, name := range sortedNames(t.Latency) {
+		if _, err := parseLatencyStat(name); err != nil {
+			errs = append(errs, err)
+		}
+
+		if t.Latency[name] <= 0 {
+			errs = append(errs, fmt.Errorf("latency %s must be greater than 0", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CheckThresholds checks the result against thresholds, records the outcome
+// of each one in Thresholds and reports whether all of them passed.
+func (r *SimulationResult) CheckThresholds(t *Thresholds) bool {
+	r.Thresholds = nil
+	if t == nil {
+		return true
+	}
+
+	if t.MaxErrorRate != nil {
+		rate := 0.0
+		if r.Summary.Requests > 0 {
+			rate = float64(r.Summary.FailedRequests) / float64(r.Summary.Requests)
+		}
+
+		r.Thresholds = append(r.Thresholds, &ThresholdResult{
+			Name:   "errorRate",
+			Limit:  formatRate(*t.MaxErrorRate),
+			Actual: formatRate(rate),
+			Passed: rate <= *t.MaxErrorRate,
+		})
+	}
+
+	latency := r.requestLatency()
+	for _, name := range sortedNames(t.Latency) {
+		limit := t.Latency[name].Std()
+		stat, err := parseLatencyStat(name)
+		if err != nil {
+			r.Thresholds = append(r.Thresholds, &ThresholdResult{Name: "latency " + name, Limit: limit.String(), Actual: err.Error()})
+			continue
+		}
+
+		actual := stat(latency)
+		r.Thresholds = append(r.Thresholds, &ThresholdResult{
+			Name:   "latency " + name,
+			Limit:  limit.String(),
+			Actual: actual.String(),
+			Passed: actual <= limit,
+		})
+	}
+
+	if t.MonitorsSatisfied {
+		r.Thresholds = append(r.Thresholds, &ThresholdResult{
+			Name:   "monitorsSatisfied",
+			Limit:  fmt.Sprintf("%d of %d", r.Summary.Monitors, r.Summary.Monitors),
+			Actual: fmt.Sprintf("%d of %d", r.Summary.SatisfiedMonitors, r.Summary.Monitors),
+			Passed: r.Summary.FailedMonitors == 0,
+		})
+	}
+
+	return r.ThresholdsPassed()
+}
+
+// ThresholdsPassed reports whether every checked threshold passed.
+func (r *SimulationResult) ThresholdsPassed() bool {
+	for _, threshold := range r.Thresholds {
+		if !threshold.Passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// requestLatency returns the histogram of every request of the run, the same
+// one the latency summary is read from. A result that was not recorded by a
+// run has its histogram built from its requests.
+func (r *SimulationResult) requestLatency() *histogram.Histogram {
+	if r.latency != nil {
+		return r.latency
+	}
+
+	h := histogram.New()
+	for _, request := range r.Requests {
+		h.Record(request.Latency.Std())
+	}
+
+	return h
+}
+
+type latencyStat func(h *histogram.Histogram) time.Duration
+
+func parseLatencyStat(name string) (latencyStat, error) {
+	switch name {
+	case "min":
+		return (*histogram.Histogram).Min, nil
+	case "max":
+		return (*histogram.Histogram).Max, nil
+	case "mean":
+		return (*histogram.Histogram).Mean, nil
+	}
+
+	match := percentilePattern.FindStringSubmatch(name)
+	if match == nil {
+		return nil, fmt.Errorf("unknown latency statistic %q, expected min, mean, max or a percentile such as p95", name)
+	}
+
+	p, err := strconv.ParseFloat(match[1], 64)
+	if err != nil || p > 100 {
+		return nil, fmt.Errorf("latency percentile %q must be between p0 and p100", name)
+	}
+
+	return func(h *histogram.Histogram) time.Duration { return h.Percentile(p) }, nil
+}
+
+func formatRate(rate float64) string {
+	return fmt.Sprintf("%.2f%%", rate*100)
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
@@ -0,0 +1,161 @@
+package simulation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/api"
+	"github.com/Easy-Infra-Ltd/easy-test/internal/duration"
+)
+
+// ThinkTime is how long a virtual user waits between the steps of an
+// iteration, like a person reading a page before moving on. A user of a
+// staged or duration-bound run also waits it between iterations. Each wait is
+// picked at random between Min and Max, or is always Min without a Max.
+type ThinkTime struct {
+	Min duration.Duration `json:"min"`
+	Max duration.Duration `json:"max,omitempty"`
+}
+
+func (t *ThinkTime) Validate() error {
+	if t == nil {
+		return nil
+	}
+
+	var errs []error
+	if t.Min < 0 || t.Max < 0 {
+		errs = append(errs, fmt.Errorf("think time can not be negative"))
+	}
+
+	if t.Max != 0 && t.Max < t.Min {
+		errs = append(errs, fmt.Errorf("think time max %s can not be less than min %s", t.Max, t.Min))
+	}
+
+	return errors.Join(errs...)
+}
+
+// next returns how long to wait before the next step.
+func (t *ThinkTime) next() time.Duration {
+	if t == nil {
+		return 0
+	}
+
+	if t.Max <= t.Min {
+		return t.Min.Std()
+	}
+
+	return t.Min.Std() + rand.N(t.Max.Std()-t.Min.Std())
+}
+
+// wait waits for the next think time, returning early when ctx is done.
+func (t *ThinkTime) wait(ctx context.Context) {
+	d := t.next()
+	if d <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// VirtualUser is one simulated client of a target. It has its own cookies and
+// connections, see api.Session, and keeps its variables from one iteration to
+// the next, so a value a step extracts, such as a login token, is still set
+// on the user's next iteration. A user runs one iteration at a time.
+type VirtualUser struct {
+	target    *SimulationTarget
+	client    int
+	session   *api.Session
+	mutex     sync.Mutex
+	vars      map[string]any
+	iteration int
+}
+
+func NewVirtualUser(target *SimulationTarget, client int) *VirtualUser {
+	return &VirtualUser{
+		target:  target,
+		client:  client,
+		session: api.NewSession(),
+		vars:    make(map[string]any),
+	}
+}
+
+// begin waits for the user's previous iteration to finish, then starts the
+// next one with vars added to the user's variables. It returns the variables
+// of the iteration, to be used until end is called.
+func (u *VirtualUser) begin(vars map[string]any) map[string]any {
+	u.mutex.Lock()
+
+	maps.Copy(u.vars, vars)
+	u.vars[IterationVar] = u.iteration
+	u.iteration++
+
+	return u.vars
+}
+
+// end finishes the iteration started by begin.
+func (u *VirtualUser) end() {
+	u.mutex.Unlock()
+}
+
+// virtualUsers holds the users of a run, one for each client of each target.
+type virtualUsers struct {
+	mutex sync.Mutex
+	users map[*SimulationTarget]map[int]*VirtualUser
+}
+
+func newVirtualUsers() *virtualUsers {
+	return &virtualUsers{users: make(map[*SimulationTarget]map[int]*VirtualUser)}
+}
+
+// get returns the user of a client of target, creating it on first use.
+func (v *virtualUsers) get(target *SimulationTarget, client int) *VirtualUser {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	users, ok := v.users[target]
+	if !ok {
+		users = make(map[int]*VirtualUser)
+		v.users[target] = users
+	}
+
+	user, ok := users[client]
+	if !ok {
+		user = NewVirtualUser(target, client)
+		users[client] = user
+	}
+
+	return user
+}
+
+// release closes a user that will not run again.
+func (v *virtualUsers) release(user *VirtualUser) {
+	v.mutex.Lock()
+	delete(v.users[user.target], user.client)
+	v.mutex.Unlock()
+
+	user.session.Close()
+}
+
+// close closes every user left.
+func (v *virtualUsers) close() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	for _, users := range v.users {
+		for _, user := range users {
+			user.session.Close()
+		}
+	}
+	v.users = make(map[*SimulationTarget]map[int]*VirtualUser)
+}
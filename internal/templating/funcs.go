@@ -0,0 +1,108 @@
+package templating
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const randomAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+var (
+	firstNames = []string{
+		"Ada", "Alan", "Barbara", "Claude", "Dennis", "Edsger", "Frances", "Grace",
+		"Hedy", "Ivan", "Joan", "Ken", "Linus", "Margaret", "Niklaus", "Radia",
+		"Shafi", "Tim", "Vint", "Yukihiro",
+	}
+
+	lastNames = []string{
+		"Allen", "Berners-Lee", "Cerf", "Dijkstra", "Hamilton", "Hopper", "Kay",
+		"Knuth", "Lamarr", "Liskov", "Lovelace", "Matsumoto", "Perlman", "Ritchie",
+		"Shannon", "Sutherland", "Thompson", "Torvalds", "Turing", "Wirth",
+	}
+
+	emailDomains = []string{"example.com", "example.org", "example.net"}
+
+	sequences sync.Map
+)
+
+// Funcs are the generator functions available to every template.
+var Funcs = template.FuncMap{
+	"uuid":          func() string { return uuid.NewString() },
+	"now":           func() string { return time.Now().UTC().Format(time.RFC3339) },
+	"nowFormat":     func(layout string) string { return time.Now().UTC().Format(layout) },
+	"nowUnix":       func() int64 { return time.Now().Unix() },
+	"nowUnixMilli":  func() int64 { return time.Now().UnixMilli() },
+	"seq":           seq,
+	"randInt":       randInt,
+	"randString":    randString,
+	"randChoice":    randChoice,
+	"fakeFirstName": func() string { return pick(firstNames) },
+	"fakeLastName":  func() string { return pick(lastNames) },
+	"fakeName":      func() string { return pick(firstNames) + " " + pick(lastNames) },
+	"fakeEmail":     fakeEmail,
+	"json":          toJSON,
+}
+
+// seq returns the next value of a counter shared by the whole process,
+// starting at 1. Passing a name uses an independent counter for that name.
+func seq(name ...string) int64 {
+	key := strings.Join(name, ".")
+	counter, _ := sequences.LoadOrStore(key, &atomic.Int64{})
+	return counter.(*atomic.Int64).Add(1)
+}
+
+// randInt returns a random integer in the closed range [min, max].
+func randInt(min, max int) (int, error) {
+	if max < min {
+		return 0, fmt.Errorf("randInt max %d is less than min %d", max, min)
+	}
+
+	return min + rand.IntN(max-min+1), nil
+}
+
+func randString(n int) (string, error) {
+	if n < 0 {
+		return "", fmt.Errorf("randString length can not be negative, got %d", n)
+	}
+
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = randomAlphabet[rand.IntN(len(randomAlphabet))]
+	}
+
+	return string(b), nil
+}
+
+func randChoice(choices ...any) (any, error) {
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("randChoice needs at least one choice")
+	}
+
+	return choices[rand.IntN(len(choices))], nil
+}
+
+func fakeEmail() string {
+	local := strings.ToLower(pick(firstNames) + "." + pick(lastNames))
+	return fmt.Sprintf("%s.%d@%s", local, rand.IntN(100000), pick(emailDomains))
+}
+
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func pick(values []string) string {
+	return values[rand.IntN(len(values))]
+}
@@ -0,0 +1,95 @@
+package templating_test
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/templating"
+)
+
+func TestGeneratorsAreUnique(t *testing.T) {
+	for _, text := range []string{"{{uuid}}", `{{seq "unique-test"}}`, "{{randString 24}}"} {
+		t.Run(text, func(t *testing.T) {
+			seen := map[string]bool{}
+			for i := 0; i < 100; i++ {
+				result, err := templating.Render(text, nil)
+				if err != nil {
+					t.Fatalf("Render(%q) unexpected error: %v", text, err)
+				}
+
+				if seen[result] {
+					t.Fatalf("Render(%q) returned %q twice", text, result)
+				}
+				seen[result] = true
+			}
+		})
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		pattern string
+	}{
+		{name: "UUID", text: "{{uuid}}", pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`},
+		{name: "Now", text: "{{now}}", pattern: `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`},
+		{name: "NowFormat", text: `{{nowFormat "2006-01-02"}}`, pattern: `^\d{4}-\d{2}-\d{2}$`},
+		{name: "NowUnix", text: "{{nowUnix}}", pattern: `^\d{10}$`},
+		{name: "RandString", text: "{{randString 12}}", pattern: `^[A-Za-z0-9]{12}$`},
+		{name: "RandChoice", text: `{{randChoice "a" "b"}}`, pattern: `^(a|b)$`},
+		{name: "FakeName", text: "{{fakeName}}", pattern: `^[A-Z][a-z]+ [A-Z][A-Za-z-]+$`},
+		{name: "FakeEmail", text: "{{fakeEmail}}", pattern: `^[a-z-]+\.[a-z-]+\.\d+@example\.(com|org|net)$`},
+		{name: "JSON", text: `{{json "quoted \"value\""}}`, pattern: `^"quoted \\"value\\""$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := templating.Render(tt.text, nil)
+			if err != nil {
+				t.Fatalf("Render(%q) unexpected error: %v", tt.text, err)
+			}
+
+			if !regexp.MustCompile(tt.pattern).MatchString(result) {
+				t.Errorf("Render(%q) = %q, want match for %s", tt.text, result, tt.pattern)
+			}
+		})
+	}
+}
+
+func TestRandInt(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		result, err := templating.Render("{{randInt 5 10}}", nil)
+		if err != nil {
+			t.Fatalf("Render() unexpected error: %v", err)
+		}
+
+		n, _ := strconv.Atoi(result)
+		if n < 5 || n > 10 {
+			t.Fatalf("randInt 5 10 = %d, want a value between 5 and 10", n)
+		}
+	}
+
+	if _, err := templating.Render("{{randInt 10 5}}", nil); err == nil {
+		t.Errorf("randInt with max below min expected error but got none")
+	}
+}
+
+func TestSeqCounters(t *testing.T) {
+	first, _ := templating.Render(`{{seq "counter-a"}}`, nil)
+	second, _ := templating.Render(`{{seq "counter-a"}}`, nil)
+	other, _ := templating.Render(`{{seq "counter-b"}}`, nil)
+
+	if first != "1" || second != "2" || other != "1" {
+		t.Errorf("seq counters = %s, %s, %s, want 1, 2, 1", first, second, other)
+	}
+}
+
+func TestNoEnvironmentAccess(t *testing.T) {
+	t.Setenv("EASY_TEST_TOKEN", "secret")
+
+	if err := templating.Validate(`{{env "EASY_TEST_TOKEN"}}`); err == nil {
+		t.Errorf("Validate() of a template reading the environment expected error but got none")
+	}
+}
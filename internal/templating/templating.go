@@ -0,0 +1,151 @@
+package templating
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+const actionStart = "{{"
+
+var (
+	cache sync.Map
+
+	referencePattern = regexp.MustCompile(`^\{\{\s*\.([A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*)\s*\}\}$`)
+)
+
+// IsTemplate reports whether text contains any template actions.
+func IsTemplate(text string) bool {
+	return strings.Contains(text, actionStart)
+}
+
+func parse(text string) (*template.Template, error) {
+	if cached, ok := cache.Load(text); ok {
+		return cached.(*template.Template), nil
+	}
+
+	tmpl, err := template.New("").Option("missingkey=error").Funcs(Funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %q: %w", text, err)
+	}
+
+	cache.Store(text, tmpl)
+	return tmpl, nil
+}
+
+// Validate checks that text is a well formed template.
+func Validate(text string) error {
+	if !IsTemplate(text) {
+		return nil
+	}
+
+	_, err := parse(text)
+	return err
+}
+
+// Render executes text as a Go template with vars as its data.
+func Render(text string, vars map[string]any) (string, error) {
+	if !IsTemplate(text) {
+		return text, nil
+	}
+
+	tmpl, err := parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	if vars == nil {
+		vars = map[string]any{}
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, vars); err != nil {
+		return "", fmt.Errorf("failed to render template %q: %w", text, err)
+	}
+
+	return sb.String(), nil
+}
+
+// RenderValue renders every string inside a decoded JSON value. A string that
+// is only a reference such as "{{.id}}" is replaced by the referenced value
+// itself so numbers, booleans and objects keep their type.
+func RenderValue(value any, vars map[string]any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		if ref, ok := lookupReference(v, vars); ok {
+			return ref, nil
+		}
+
+		return Render(v, vars)
+	case map[string]any:
+		rendered := make(map[string]any, len(v))
+		for k, item := range v {
+			r, err := RenderValue(item, vars)
+			if err != nil {
+				return nil, err
+			}
+
+			rendered[k] = r
+		}
+
+		return rendered, nil
+	case []any:
+		rendered := make([]any, 0, len(v))
+		for _, item := range v {
+			r, err := RenderValue(item, vars)
+			if err != nil {
+				return nil, err
+			}
+
+			rendered = append(rendered, r)
+		}
+
+		return rendered, nil
+	default:
+		return value, nil
+	}
+}
+
+// RenderHeaders renders every value in headers.
+func RenderHeaders(headers map[string][]string, vars map[string]any) (map[string][]string, error) {
+	rendered := make(map[string][]string, len(headers))
+	for k, values := range headers {
+		out := make([]string, 0, len(values))
+		for _, v := range values {
+			r, err := Render(v, vars)
+			if err != nil {
+				return nil, fmt.Errorf("header %s: %w", k, err)
+			}
+
+			out = append(out, r)
+		}
+
+		rendered[k] = out
+	}
+
+	return rendered, nil
+}
+
+func lookupReference(text string, vars map[string]any) (any, bool) {
+	match := referencePattern.FindStringSubmatch(text)
+	if match == nil {
+		return nil, false
+	}
+
+	var current any = vars
+	for _, key := range strings.Split(match[1], ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = object[key]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
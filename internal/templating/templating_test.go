@@ -0,0 +1,90 @@
+package templating_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Easy-Infra-Ltd/easy-test/internal/templating"
+)
+
+func TestRender(t *testing.T) {
+	vars := map[string]any{
+		"id":    "abc-123",
+		"order": map[string]any{"status": "shipped"},
+	}
+
+	tests := []struct {
+		name        string
+		text        string
+		expected    string
+		expectError bool
+	}{
+		{name: "PlainText", text: "/orders", expected: "/orders"},
+		{name: "Variable", text: "/orders/{{.id}}", expected: "/orders/abc-123"},
+		{name: "NestedVariable", text: "{{.order.status}}", expected: "shipped"},
+		{name: "MissingVariable", text: "/orders/{{.nope}}", expectError: true},
+		{name: "InvalidTemplate", text: "/orders/{{.id", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := templating.Render(tt.text, vars)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Render(%q) expected error but got none", tt.text)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Render(%q) unexpected error: %v", tt.text, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Render(%q) = %q, want %q", tt.text, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderValue(t *testing.T) {
+	vars := map[string]any{
+		"id":    "abc-123",
+		"total": float64(42),
+		"paid":  true,
+	}
+
+	value := map[string]any{
+		"id":      "{{.id}}",
+		"total":   "{{ .total }}",
+		"paid":    "{{.paid}}",
+		"summary": "order {{.id}} for {{.total}}",
+		"items":   []any{"{{.id}}", float64(1)},
+	}
+
+	expected := map[string]any{
+		"id":      "abc-123",
+		"total":   float64(42),
+		"paid":    true,
+		"summary": "order abc-123 for 42",
+		"items":   []any{"abc-123", float64(1)},
+	}
+
+	result, err := templating.RenderValue(value, vars)
+	if err != nil {
+		t.Fatalf("RenderValue() unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("RenderValue() = %#v, want %#v", result, expected)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := templating.Validate("{{.id}}"); err != nil {
+		t.Errorf("Validate() unexpected error: %v", err)
+	}
+
+	if err := templating.Validate("{{.id"); err == nil {
+		t.Errorf("Validate() expected error but got none")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
@@ -23,10 +24,9 @@ type Task interface {
 }
 
 type worker struct {
-	id             uuid.UUID
-	threadPool     *ThreadPool
-	lastActiveTime time.Time
-	logger         *slog.Logger
+	id         uuid.UUID
+	threadPool *ThreadPool
+	logger     *slog.Logger
 }
 
 func NewWorker(tp *ThreadPool) *worker {
@@ -37,31 +37,43 @@ func NewWorker(tp *ThreadPool) *worker {
 
 	logger.Info("Creating new worker")
 	return &worker{
-		id:             id,
-		threadPool:     tp,
-		lastActiveTime: time.Now(),
-		logger:         logger,
+		id:         id,
+		threadPool: tp,
+		logger:     logger,
 	}
 }
 
+// start runs tasks from the queue until ctx is done, or until the worker has
+// been idle for the pool's idle timeout and is no longer needed. A worker
+// only leaves the pool while it is not taking a task, so the pool never runs
+// more tasks at once than it has workers.
 func (w *worker) start(ctx context.Context, wg *sync.WaitGroup) {
 	assert.NotNil(wg, "WaitGroup should not be nil when starting a worker")
 
+	idle := time.NewTimer(w.threadPool.idleTimeout)
+	defer idle.Stop()
+
 	for {
 		select {
-		case task := <-w.threadPool.taskQueue:
-			w.lastActiveTime = time.Now()
+		case task, ok := <-w.threadPool.taskQueue:
+			if !ok {
+				return
+			}
+
 			w.logger.Info("Worker executing task")
 			task.Run()
 			wg.Done()
+		case <-idle.C:
+			if w.threadPool.retire(w) {
+				w.logger.Info("Stopping idle worker")
+				return
+			}
 		case <-ctx.Done():
 			return
 		}
-	}
-}
 
-func (w *worker) stop() {
-	w.logger.Info("Stopping worker")
+		idle.Reset(w.threadPool.idleTimeout)
+	}
 }
 
 type ThreadPool struct {
@@ -75,12 +87,12 @@ type ThreadPool struct {
 	cancel      context.CancelFunc
 	logger      *slog.Logger
 	mutex       sync.Mutex
+	reserved    int
 }
 
 func NewThreadPool(minWorkers int, maxWorkers int, idleTimeout time.Duration) *ThreadPool {
-	assert.Assert(minWorkers >= 0, "minWorkers can never be less than 0")
-	assert.Assert(minWorkers < maxWorkers, fmt.Sprintf("minWorkers of %d must be less than maxWorkers of %d", minWorkers, maxWorkers))
-	assert.Assert(maxWorkers > MIN_WORKERS, fmt.Sprintf("you should never have a thread pool with %d or less workers", MIN_WORKERS))
+	assert.Assert(minWorkers >= MIN_WORKERS, fmt.Sprintf("minWorkers can never be less than %d", MIN_WORKERS))
+	assert.Assert(minWorkers <= maxWorkers, fmt.Sprintf("minWorkers of %d must not be more than maxWorkers of %d", minWorkers, maxWorkers))
 	assert.Assert(maxWorkers <= MAX_WORKERS, fmt.Sprintf("thread pool max workers should never exceed %d", MAX_WORKERS))
 	assert.Assert(idleTimeout > MIN_IDLE_TIME, fmt.Sprintf("Threadpool timeout must be greated than %d seconds", MIN_IDLE_TIME))
 
@@ -108,8 +120,6 @@ func (tp *ThreadPool) Run() {
 		tp.addWorker()
 	}
 	tp.mutex.Unlock()
-
-	go tp.monitorIdleWorkers()
 }
 
 func (tp *ThreadPool) Add(task Task) error {
@@ -128,6 +138,64 @@ func (tp *ThreadPool) Add(task Task) error {
 	}
 }
 
+// Reserve holds a free worker for a task added later with AddReserved, so
+// the task only has to be built once it is sure to run. It returns false when
+// every worker is already held. A reservation that is not used has to be
+// given back with Unreserve.
+func (tp *ThreadPool) Reserve() bool {
+	tp.mutex.Lock()
+	defer tp.mutex.Unlock()
+
+	if tp.reserved >= tp.maxWorkers {
+		return false
+	}
+
+	tp.reserved++
+	if len(tp.workerPool) < tp.reserved {
+		tp.addWorker()
+	}
+
+	return true
+}
+
+// AddReserved adds task to run on a worker held by Reserve. It returns false,
+// giving the worker back, when the thread pool has been stopped.
+func (tp *ThreadPool) AddReserved(task Task) bool {
+	assert.NotNil(task, "Task can not be nil when added to the thread pool")
+
+	tp.logger.Info(fmt.Sprintf("Adding Task to queue %s", task.GetName()))
+
+	tp.wg.Add(1)
+	select {
+	case <-tp.ctx.Done():
+		tp.wg.Done()
+		tp.Unreserve()
+		return false
+	case tp.taskQueue <- &reservedTask{Task: task, tp: tp}:
+		return true
+	}
+}
+
+// Unreserve gives back a worker held by Reserve that no task was added for.
+func (tp *ThreadPool) Unreserve() {
+	tp.mutex.Lock()
+	defer tp.mutex.Unlock()
+
+	tp.reserved--
+}
+
+// reservedTask is a task added to a reserved worker, which frees the worker
+// for the next reservation once it has run.
+type reservedTask struct {
+	Task
+	tp *ThreadPool
+}
+
+func (t *reservedTask) Run() {
+	defer t.tp.Unreserve()
+	t.Task.Run()
+}
+
 func (tp *ThreadPool) scaleUp() {
 	tp.mutex.Lock()
 	defer tp.mutex.Unlock()
@@ -146,35 +214,19 @@ func (tp *ThreadPool) addWorker() {
 	go w.start(tp.ctx, tp.wg)
 }
 
-func (tp *ThreadPool) monitorIdleWorkers() {
-	ticker := time.NewTicker(tp.idleTimeout / 2)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			tp.cleanupIdleWorkers()
-		case <-tp.ctx.Done():
-			tp.logger.Info("Context resolved, stopping threadpool monitorIdleWorkers")
-			return
-		}
-	}
-}
-
-func (tp *ThreadPool) cleanupIdleWorkers() {
+// retire removes an idle worker from the pool, unless the pool needs it to
+// keep minWorkers or a worker for every reservation. It reports whether the
+// worker was removed and should stop.
+func (tp *ThreadPool) retire(w *worker) bool {
 	tp.mutex.Lock()
 	defer tp.mutex.Unlock()
 
-	activeWorkers := make([]*worker, 0, len(tp.workerPool))
-	for _, w := range tp.workerPool {
-		if time.Since(w.lastActiveTime) > tp.idleTimeout && len(tp.workerPool) > tp.minWorkers {
-			w.stop()
-		} else {
-			activeWorkers = append(activeWorkers, w)
-		}
+	if len(tp.workerPool) <= max(tp.minWorkers, tp.reserved) {
+		return false
 	}
 
-	tp.workerPool = activeWorkers
+	tp.workerPool = slices.DeleteFunc(tp.workerPool, func(other *worker) bool { return other == w })
+	return true
 }
 
 func (tp *ThreadPool) Wait() {
@@ -185,9 +237,8 @@ func (tp *ThreadPool) Stop() {
 	tp.cancel()
 
 	tp.mutex.Lock()
-	for _, w := range tp.workerPool {
-		w.stop()
-	}
+	defer tp.mutex.Unlock()
 
+	tp.logger.Info(fmt.Sprintf("Stopping %d workers", len(tp.workerPool)))
 	close(tp.taskQueue)
 }
@@ -3,6 +3,7 @@ package threadpool_test
 import (
 	"fmt"
 	"log/slog"
+	"sync"
 	"testing"
 	"time"
 
@@ -116,3 +117,110 @@ func TestThreadPool(t *testing.T) {
 		})
 	}
 }
+
+type blockingTask struct {
+	release chan struct{}
+}
+
+func (t *blockingTask) GetName() string {
+	return "blocking task"
+}
+
+func (t *blockingTask) Run() {
+	<-t.release
+}
+
+// concurrency tracks how many countingTasks are running at once.
+type concurrency struct {
+	mutex   sync.Mutex
+	running int
+	peak    int
+}
+
+type countingTask struct {
+	concurrency *concurrency
+}
+
+func (t *countingTask) GetName() string {
+	return "counting task"
+}
+
+func (t *countingTask) Run() {
+	c := t.concurrency
+	c.mutex.Lock()
+	c.running++
+	c.peak = max(c.peak, c.running)
+	c.mutex.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	c.mutex.Lock()
+	c.running--
+	c.mutex.Unlock()
+}
+
+func TestThreadPoolIdleWorkersStop(t *testing.T) {
+	const maxWorkers = 3
+
+	tp := threadpool.NewThreadPool(1, maxWorkers, 20*time.Millisecond)
+	tp.Run()
+	defer tp.Stop()
+
+	c := &concurrency{}
+	addAll := func(n int) {
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				tp.Add(&countingTask{concurrency: c})
+			}()
+		}
+		wg.Wait()
+		tp.Wait()
+	}
+
+	addAll(maxWorkers)
+
+	// Long enough for the idle workers to leave the pool before the pool
+	// has to grow again.
+	time.Sleep(200 * time.Millisecond)
+	addAll(10 * maxWorkers)
+
+	if got := c.peak; got > maxWorkers {
+		t.Errorf("ran %d tasks at once after idle workers stopped, want at most %d", got, maxWorkers)
+	}
+}
+
+func TestThreadPoolReserve(t *testing.T) {
+	tp := threadpool.NewThreadPool(1, 1, 5*time.Second)
+	tp.Run()
+	defer tp.Stop()
+
+	if !tp.Reserve() {
+		t.Fatalf("Reserve() with a free worker returned false")
+	}
+
+	if tp.Reserve() {
+		t.Errorf("Reserve() with the only worker reserved returned true")
+	}
+
+	tp.Unreserve()
+
+	release := make(chan struct{})
+	if !tp.Reserve() || !tp.AddReserved(&blockingTask{release: release}) {
+		t.Fatalf("Reserve() and AddReserved() after Unreserve() returned false")
+	}
+
+	if tp.Reserve() {
+		t.Errorf("Reserve() with the only worker running a reserved task returned true")
+	}
+
+	close(release)
+	tp.Wait()
+
+	if !tp.Reserve() {
+		t.Errorf("Reserve() after the reserved task finished returned false")
+	}
+	tp.Unreserve()
+}